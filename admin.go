@@ -6,19 +6,19 @@
 package cloudinary
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"sort"
 	"strconv"
+	"strings"
+	"time"
 )
 
 const (
-	baseAdminUrl = "https://api.cloudinary.com/v1_1"
-)
-
-const (
+	pathPing            = "/ping"
 	pathListAllImages   = "/resources/image"
 	pathListAllRaws     = "/resources/raw"
 	pathListSingleImage = "/resources/image/upload/"
@@ -27,9 +27,41 @@ const (
 
 const (
 	maxResults = 2048
+	// Maximum number of public ids per request
+	maxPublicIds = 100
 )
 
+// resourceTypeName returns the resource type as named in API paths.
+func resourceTypeName(rtype ResourceType) string {
+	switch rtype {
+	case PdfType:
+		return pdfType
+	case VideoType:
+		return videoType
+	case RawType:
+		return rawType
+	case AutoType:
+		return autoType
+	}
+	return imageType
+}
+
+// resourceTypeFromName returns the resource type named name in API
+// responses, ImageType if unknown.
+func resourceTypeFromName(name string) ResourceType {
+	switch name {
+	case videoType:
+		return VideoType
+	case rawType:
+		return RawType
+	}
+	return ImageType
+}
+
 func (s *Service) dropAllResources(rtype ResourceType, w io.Writer) error {
+	if err := s.writable(); err != nil {
+		return err
+	}
 	qs := url.Values{
 		"max_results": []string{strconv.FormatInt(maxResults, 10)},
 	}
@@ -37,8 +69,12 @@ func (s *Service) dropAllResources(rtype ResourceType, w io.Writer) error {
 	if rtype == RawType {
 		path = pathListAllRaws
 	}
+	errs := make([]FileError, 0)
 	for {
-		resp, err := http.Get(fmt.Sprintf("%s%s?%s", s.adminURI, path, qs.Encode()))
+		resp, err := s.client.Get(fmt.Sprintf("%s%s?%s", s.adminURL(), path, qs.Encode()))
+		if err != nil {
+			return err
+		}
 		m, err := handleHttpResponse(resp)
 		if err != nil {
 			return err
@@ -48,9 +84,18 @@ func (s *Service) dropAllResources(rtype ResourceType, w io.Writer) error {
 			if w != nil {
 				fmt.Fprintf(w, "Deleting %s ... ", publicId)
 			}
-			if err := s.Delete(publicId, "", rtype); err != nil {
-				// Do not return. Report the error but continue through the list.
-				fmt.Fprintf(w, "Error: %s: %s\n", publicId, err.Error())
+			res, err := s.Delete(publicId, "", rtype, false)
+			if err != nil {
+				errs = append(errs, FileError{Path: publicId, Err: err})
+				if s.failFast {
+					return &errs[len(errs)-1]
+				}
+				// Report the error but continue through the list.
+				if w != nil {
+					fmt.Fprintf(w, "Error: %s: %s\n", publicId, err.Error())
+				}
+			} else if w != nil {
+				fmt.Fprintln(w, res.Status())
 			}
 		}
 		if e, ok := m["next_cursor"]; ok {
@@ -59,7 +104,9 @@ func (s *Service) dropAllResources(rtype ResourceType, w io.Writer) error {
 			break
 		}
 	}
-
+	if len(errs) > 0 {
+		return &BatchError{errs}
+	}
 	return nil
 }
 
@@ -78,64 +125,145 @@ func (s *Service) DropAllRaws(w io.Writer) error {
 // DropAll deletes all remote resources (both images and raw files) from Cloudinary.
 // File names are written to io.Writer if available.
 func (s *Service) DropAll(w io.Writer) error {
-	if err := s.DropAllImages(w); err != nil {
-		return err
+	err := s.DropAllImages(w)
+	if err != nil {
+		if _, ok := err.(*BatchError); !ok || s.failFast {
+			return err
+		}
 	}
-	if err := s.DropAllRaws(w); err != nil {
+	rerr := s.DropAllRaws(w)
+	if rerr == nil {
 		return err
 	}
-	return nil
+	// Merge the errors of both resource types
+	if b, ok := err.(*BatchError); ok {
+		if rb, ok := rerr.(*BatchError); ok {
+			return &BatchError{append(b.errs, rb.errs...)}
+		}
+	}
+	return rerr
 }
 
 func (s *Service) doGetResources(rtype ResourceType) ([]*Resource, error) {
-	qs := url.Values{
-		"max_results": []string{strconv.FormatInt(maxResults, 10)},
-	}
+	return s.doGetResourcesPath(resourcesPath(rtype))
+}
+
+// resourcesPath returns the admin API path listing the rtype resources.
+func resourcesPath(rtype ResourceType) string {
 	path := pathListAllImages
 	if rtype == RawType {
 		path = pathListAllRaws
 	} else if rtype == VideoType {
 		path = pathListAllVideos
 	}
+	return path
+}
+
+// doGetResourcesPath fetches all the resources listed by an admin API
+// path, following pagination.
+func (s *Service) doGetResourcesPath(path string) ([]*Resource, error) {
+	allres, _, err := s.doGetResourcesPage(path, nil, "", 0)
+	return allres, err
+}
+
+// doGetResourcesPage fetches the resources listed by an admin API path
+// with extra query parameters, if any, starting at cursor if set, and
+// following pagination until max resources are fetched, or all of them
+// if max is zero. The cursor of the next resources is returned, if any.
+func (s *Service) doGetResourcesPage(path string, params url.Values, cursor string, max int) ([]*Resource, string, error) {
+	qs := url.Values{
+		"max_results": []string{strconv.FormatInt(maxResults, 10)},
+		"tags":        []string{"true"},
+	}
+	for k, v := range params {
+		qs[k] = v
+	}
+	if cursor != "" {
+		qs.Set("next_cursor", cursor)
+	}
 	allres := make([]*Resource, 0)
 	for {
-		resp, err := http.Get(fmt.Sprintf("%s%s?%s", s.adminURI, path, qs.Encode()))
+		if max > 0 && max-len(allres) < maxResults {
+			// Don't fetch more than needed, so that the cursor is exact
+			qs.Set("max_results", strconv.Itoa(max-len(allres)))
+		}
+		resp, err := s.client.Get(fmt.Sprintf("%s%s?%s", s.adminURL(), path, qs.Encode()))
 		if err != nil {
-			return nil, err
+			return nil, "", err
 		}
 
 		rs := new(resourceList)
-		dec := json.NewDecoder(resp.Body)
-		if err := dec.Decode(rs); err != nil {
-			return nil, err
+		if err := decodeResponse(resp, rs); err != nil {
+			return nil, "", err
 		}
 		for _, res := range rs.Resources {
 			allres = append(allres, res)
 		}
-		if rs.NextCursor > 0 {
-			qs.Set("next_cursor", strconv.FormatInt(rs.NextCursor, 10))
-		} else {
-			break
+		done := rs.NextCursor == "" || (max > 0 && len(allres) >= max)
+		if s.listProgress != nil {
+			s.listProgress(len(allres), done)
 		}
+		if done {
+			return allres, rs.NextCursor, nil
+		}
+		qs.Set("next_cursor", rs.NextCursor)
 	}
-	return allres, nil
 }
 
-func (s *Service) doGetResourceDetails(publicId string) (*ResourceDetails, error) {
+func (s *Service) doGetResourceDetails(publicId string, rtype ResourceType, qs url.Values) (*ResourceDetails, error) {
 	path := pathListSingleImage
+	if rtype != ImageType {
+		path = fmt.Sprintf("/resources/%s/upload/", resourceTypeName(rtype))
+	}
 
-	resp, err := http.Get(fmt.Sprintf("%s%s%s", s.adminURI, path, publicId))
+	if d := s.detailsCache.get(publicId, rtype, qs); d != nil {
+		return d, nil
+	}
+	uri := fmt.Sprintf("%s%s%s", s.adminURL(), path, publicId)
+	if len(qs) > 0 {
+		uri += "?" + qs.Encode()
+	}
+	resp, err := s.client.Get(uri)
 	if err != nil {
 		return nil, err
 	}
 	details := new(ResourceDetails)
-	dec := json.NewDecoder(resp.Body)
-	if err := dec.Decode(details); err != nil {
+	if err := decodeResponse(resp, details); err != nil {
 		return nil, err
 	}
+	s.detailsCache.put(publicId, rtype, qs, details)
 	return details, nil
 }
 
+// Exists reports whether a resource matching publicId exists.
+func (s *Service) Exists(publicId string, rtype ResourceType) (bool, error) {
+	// Always asked again, e.g. by VerifyDeleted, to see a change
+	s.detailsCache.evict(publicId)
+	_, err := s.doGetResourceDetails(publicId, rtype, nil)
+	if isNotFound(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// ExistMany reports whether resources match publicIds, by public id.
+// Unlike calling Exists for each id, ids are checked in batches of 100
+// with ResourcesByIDs, i.e. one admin API call per batch.
+func (s *Service) ExistMany(publicIds []string, rtype ResourceType) (map[string]bool, error) {
+	found, err := s.ResourcesByIDs(publicIds, rtype)
+	if err != nil {
+		return nil, err
+	}
+	exist := make(map[string]bool, len(publicIds))
+	for _, id := range publicIds {
+		exist[id] = false
+	}
+	for _, res := range found {
+		exist[res.PublicId] = true
+	}
+	return exist, nil
+}
+
 // Resources returns a list of all uploaded resources. They can be
 // images or raw files, depending on the resource type passed in rtype.
 // Cloudinary can return a limited set of results. Pagination is supported,
@@ -144,7 +272,368 @@ func (s *Service) Resources(rtype ResourceType) ([]*Resource, error) {
 	return s.doGetResources(rtype)
 }
 
+// ResourceFields are the fields which can be requested with
+// ResourcesFields, named as in the JSON encoding of a Resource.
+var ResourceFields = []string{"public_id", "format", "version", "resource_type", "bytes", "width", "height", "url", "secure_url", "tags", "created_at"}
+
+// ResourcesFields returns all the resources of type rtype with only
+// fields set, e.g. "public_id" and "bytes", to reduce the size of large
+// listings. See ResourceFields for the valid names. The public id is
+// always set.
+func (s *Service) ResourcesFields(rtype ResourceType, fields []string) ([]*Resource, error) {
+	if len(fields) == 0 {
+		return nil, errors.New("no field requested")
+	}
+	tags := "false"
+	for _, f := range fields {
+		if !IsResourceField(f) {
+			return nil, fmt.Errorf("unknown resource field %q", f)
+		}
+		if f == "tags" {
+			tags = "true"
+		}
+	}
+	params := url.Values{
+		"fields": []string{strings.Join(fields, ",")},
+		"tags":   []string{tags},
+	}
+	res, _, err := s.doGetResourcesPage(resourcesPath(rtype), params, "", 0)
+	return res, err
+}
+
+// IsResourceField reports whether name is one of ResourceFields.
+func IsResourceField(name string) bool {
+	for _, f := range ResourceFields {
+		if f == name {
+			return true
+		}
+	}
+	return false
+}
+
+// ResourcesPage returns at most max resources of type rtype, or all of
+// them if max is zero, starting at cursor, or at the first resource if
+// cursor is empty. Use the returned cursor to fetch the next resources;
+// it is empty once all the resources have been listed.
+func (s *Service) ResourcesPage(rtype ResourceType, cursor string, max int) ([]*Resource, string, error) {
+	if max < 0 {
+		return nil, "", fmt.Errorf("invalid max results %d", max)
+	}
+	return s.doGetResourcesPage(resourcesPath(rtype), nil, cursor, max)
+}
+
+// ResourcesByPrefix returns all the resources of type rtype whose public
+// id starts with prefix, e.g. "products/".
+func (s *Service) ResourcesByPrefix(prefix string, rtype ResourceType) ([]*Resource, error) {
+	path := fmt.Sprintf("/resources/%s/upload", resourceTypeName(rtype))
+	allres, _, err := s.doGetResourcesPage(path, url.Values{"prefix": []string{prefix}}, "", 0)
+	return allres, err
+}
+
+// ResourcesByTag returns all the resources tagged with tag.
+func (s *Service) ResourcesByTag(tag string, rtype ResourceType) ([]*Resource, error) {
+	return s.doGetResourcesPath(fmt.Sprintf("/resources/%s/tags/%s", resourceTypeName(rtype), url.PathEscape(tag)))
+}
+
+// ResourcesWithoutTag returns all the resources which are not tagged
+// with tag. The whole inventory is fetched and filtered locally.
+func (s *Service) ResourcesWithoutTag(tag string, rtype ResourceType) ([]*Resource, error) {
+	res, err := s.doGetResources(rtype)
+	if err != nil {
+		return nil, err
+	}
+	return withoutTag(res, tag), nil
+}
+
+// withoutTag returns the resources not tagged with tag.
+func withoutTag(res []*Resource, tag string) []*Resource {
+	untagged := make([]*Resource, 0, len(res))
+	for _, r := range res {
+		if !r.HasTag(tag) {
+			untagged = append(untagged, r)
+		}
+	}
+	return untagged
+}
+
 // GetResourceDetails gets the details of a single resource that is specified by publicId.
 func (s *Service) ResourceDetails(publicId string) (*ResourceDetails, error) {
-	return s.doGetResourceDetails(publicId)
+	return s.doGetResourceDetails(publicId, ImageType, url.Values{"coordinates": []string{"true"}})
+}
+
+// RawResourceDetails gets the details of a single raw file that is
+// specified by publicId, extension included.
+func (s *Service) RawResourceDetails(publicId string) (*ResourceDetails, error) {
+	return s.doGetResourceDetails(publicId, RawType, nil)
+}
+
+// Colors returns the dominant colors of the publicId image, the most
+// present first.
+func (s *Service) Colors(publicId string) ([]Color, error) {
+	d, err := s.doGetResourceDetails(publicId, ImageType, url.Values{"colors": []string{"true"}})
+	if err != nil {
+		return nil, err
+	}
+	return d.Colors, nil
+}
+
+// FindSimilar returns the images which look like the publicId image,
+// i.e. whose perceptual hash is within maxDistance bits of its own.
+// Cloudinary only returns perceptual hashes in resource details so
+// one request per image is issued: this can be slow on large accounts.
+func (s *Service) FindSimilar(publicId string, maxDistance int) ([]*Resource, error) {
+	phash := url.Values{"phash": []string{"true"}}
+	ref, err := s.doGetResourceDetails(publicId, ImageType, phash)
+	if err != nil {
+		return nil, err
+	}
+	if ref.Phash == "" {
+		return nil, fmt.Errorf("no perceptual hash available for %s", publicId)
+	}
+	all, err := s.doGetResources(ImageType)
+	if err != nil {
+		return nil, err
+	}
+	candidates := make([]*Resource, 0, len(all))
+	for _, r := range all {
+		if r.PublicId == publicId {
+			continue
+		}
+		d, err := s.doGetResourceDetails(r.PublicId, ImageType, phash)
+		if err != nil {
+			return nil, err
+		}
+		r.Phash = d.Phash
+		candidates = append(candidates, r)
+	}
+	return similarResources(ref.Phash, candidates, maxDistance), nil
+}
+
+// similarResources returns the resources whose phash is within
+// maxDistance of phash, closest first. Resources without a valid
+// phash are ignored.
+func similarResources(phash string, res []*Resource, maxDistance int) []*Resource {
+	dist := make(map[*Resource]int)
+	similar := make([]*Resource, 0)
+	for _, r := range res {
+		d, err := PhashDistance(phash, r.Phash)
+		if err != nil || d > maxDistance {
+			continue
+		}
+		dist[r] = d
+		similar = append(similar, r)
+	}
+	sort.SliceStable(similar, func(i, j int) bool {
+		return dist[similar[i]] < dist[similar[j]]
+	})
+	return similar
+}
+
+// Ping checks that the Cloudinary service is reachable and that the
+// credentials are accepted by the admin API.
+func (s *Service) Ping() error {
+	resp, err := s.client.Get(fmt.Sprintf("%s%s", s.adminURL(), pathPing))
+	if err != nil {
+		return err
+	}
+	m, err := handleHttpResponse(resp)
+	if err != nil {
+		return err
+	}
+	if st, _ := m["status"].(string); st != "ok" {
+		return fmt.Errorf("unexpected ping status: %v", m["status"])
+	}
+	return nil
+}
+
+// RateLimit is the admin API rate limit status of the account.
+type RateLimit struct {
+	Limit     int       // Requests allowed per period
+	Remaining int       // Requests left until Reset
+	Reset     time.Time // End of the current period
+}
+
+// RateLimitStatus returns the admin API rate limit status, as reported
+// by the X-FeatureRateLimit headers of a ping. The status is returned
+// even if the ping is throttled, so that callers know when they can
+// resume.
+func (s *Service) RateLimitStatus() (RateLimit, error) {
+	resp, err := s.client.Get(fmt.Sprintf("%s%s", s.adminURL(), pathPing))
+	if err != nil {
+		return RateLimit{}, err
+	}
+	rl, rerr := parseRateLimit(resp.Header)
+	var m map[string]interface{}
+	if err := decodeResponse(resp, &m); err != nil && rerr != nil {
+		return RateLimit{}, err
+	}
+	return rl, rerr
+}
+
+// parseRateLimit parses the X-FeatureRateLimit headers. The reset time
+// is either a Unix time or an HTTP date.
+func parseRateLimit(h http.Header) (RateLimit, error) {
+	var rl RateLimit
+	limit, remaining, reset := h.Get("X-FeatureRateLimit-Limit"), h.Get("X-FeatureRateLimit-Remaining"), h.Get("X-FeatureRateLimit-Reset")
+	if limit == "" || remaining == "" || reset == "" {
+		return rl, errors.New("no rate limit headers in the response")
+	}
+	var err error
+	if rl.Limit, err = strconv.Atoi(limit); err != nil {
+		return RateLimit{}, fmt.Errorf("invalid rate limit %q", limit)
+	}
+	if rl.Remaining, err = strconv.Atoi(remaining); err != nil {
+		return RateLimit{}, fmt.Errorf("invalid remaining requests %q", remaining)
+	}
+	if epoch, err := strconv.ParseInt(reset, 10, 64); err == nil {
+		rl.Reset = time.Unix(epoch, 0)
+	} else if rl.Reset, err = http.ParseTime(reset); err != nil {
+		return RateLimit{}, fmt.Errorf("invalid rate limit reset %q", reset)
+	}
+	return rl, nil
+}
+
+// ResourcesByIDs returns the resources matching publicIds. Ids are sent
+// in batches of 100, the maximum allowed by Cloudinary. Ids which don't
+// match any resource are silently ignored, so that fewer resources than
+// requested may be returned.
+func (s *Service) ResourcesByIDs(publicIds []string, rtype ResourceType) ([]*Resource, error) {
+	allres := make([]*Resource, 0, len(publicIds))
+	for start := 0; start < len(publicIds); start += maxPublicIds {
+		end := start + maxPublicIds
+		if end > len(publicIds) {
+			end = len(publicIds)
+		}
+		qs := url.Values{
+			"public_ids[]": publicIds[start:end],
+			"tags":         []string{"true"},
+		}
+		resp, err := s.client.Get(fmt.Sprintf("%s/resources/%s/upload?%s", s.adminURL(), resourceTypeName(rtype), qs.Encode()))
+		if err != nil {
+			return nil, err
+		}
+		rs := new(resourceList)
+		if err := decodeResponse(resp, rs); err != nil {
+			return nil, err
+		}
+		allres = append(allres, rs.Resources...)
+	}
+	return allres, nil
+}
+
+// DeleteVersion deletes a backed up version of the publicId resource.
+// Versions are only kept if backups are enabled for the account. The
+// current version can't be deleted this way, use Delete() instead. In
+// simulation mode, the version is checked but not deleted.
+func (s *Service) DeleteVersion(publicId string, version int, rtype ResourceType) error {
+	if err := s.writable(); err != nil {
+		return err
+	}
+	details, err := s.doGetResourceDetails(publicId, rtype, url.Values{"versions": []string{"true"}})
+	if err != nil {
+		return err
+	}
+	if details.Version == version {
+		return fmt.Errorf("%s: version %d is the current version", publicId, version)
+	}
+	for _, v := range details.Versions {
+		if v.Version != strconv.Itoa(version) {
+			continue
+		}
+		if s.simulate {
+			return nil
+		}
+		qs := url.Values{"version_ids[]": []string{v.VersionId}}
+		req, err := http.NewRequest("DELETE", fmt.Sprintf("%s/resources/backup/%s?%s", s.adminURL(), details.AssetId, qs.Encode()), nil)
+		if err != nil {
+			return err
+		}
+		resp, err := s.client.Do(req)
+		if err != nil {
+			return err
+		}
+		s.detailsCache.evict(publicId)
+		var m map[string]interface{}
+		return decodeResponse(resp, &m)
+	}
+	return fmt.Errorf("%s: no backed up version %d", publicId, version)
+}
+
+// VersionInfo describes a backed up version of a resource.
+type VersionInfo struct {
+	Version    int       // As used in delivery URLs
+	VersionId  string    // Backup id
+	Size       int       // In bytes
+	Time       time.Time // Backup time
+	Restorable bool
+	Current    bool // Whether this is the current version
+}
+
+// Versions returns the backed up versions of the publicId resource,
+// oldest first. Versions are only kept if backups are enabled for the
+// account.
+func (s *Service) Versions(publicId string, rtype ResourceType) ([]VersionInfo, error) {
+	details, err := s.doGetResourceDetails(publicId, rtype, url.Values{"versions": []string{"true"}})
+	if err != nil {
+		return nil, err
+	}
+	vs := make([]VersionInfo, 0, len(details.Versions))
+	for _, v := range details.Versions {
+		n, err := strconv.Atoi(v.Version)
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid version %q", publicId, v.Version)
+		}
+		// The backup time is informative, ignore unexpected formats
+		t, _ := time.Parse(time.RFC3339, v.Time)
+		vs = append(vs, VersionInfo{
+			Version:    n,
+			VersionId:  v.VersionId,
+			Size:       v.Size,
+			Time:       t,
+			Restorable: v.Restorable,
+			Current:    n == details.Version,
+		})
+	}
+	sort.SliceStable(vs, func(i, j int) bool { return vs[i].Version < vs[j].Version })
+	return vs, nil
+}
+
+// RestoreVersion makes a backed up version of the publicId resource its
+// current version, e.g. to revert an edit. Deleted resources are
+// restored the same way. In simulation mode, the version is checked but
+// not restored.
+func (s *Service) RestoreVersion(publicId string, version int, rtype ResourceType) error {
+	if err := s.writable(); err != nil {
+		return err
+	}
+	vs, err := s.Versions(publicId, rtype)
+	if err != nil {
+		return err
+	}
+	for _, v := range vs {
+		if v.Version != version {
+			continue
+		}
+		if v.Current {
+			return fmt.Errorf("%s: version %d is the current version", publicId, version)
+		}
+		if !v.Restorable {
+			return fmt.Errorf("%s: version %d can't be restored", publicId, version)
+		}
+		if s.simulate {
+			return nil
+		}
+		data := url.Values{
+			"public_ids[]": []string{publicId},
+			"versions[]":   []string{v.VersionId},
+		}
+		resp, err := s.client.PostForm(fmt.Sprintf("%s/resources/%s/upload/restore", s.adminURL(), resourceTypeName(rtype)), data)
+		if err != nil {
+			return err
+		}
+		s.detailsCache.evict(publicId)
+		var m map[string]interface{}
+		return decodeResponse(resp, &m)
+	}
+	return fmt.Errorf("%s: no backed up version %d", publicId, version)
 }
@@ -0,0 +1,455 @@
+// Copyright 2013 Mathias Monnerville and Anthony Baillard.
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+package cloudinary
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestResourceListTags(t *testing.T) {
+	body := `{"resources":[
+		{"public_id":"images/logo","version":1509259745,"resource_type":"image","bytes":1024,"tags":["brand","hero"]},
+		{"public_id":"images/cover","version":1509259746,"resource_type":"image","bytes":2048}
+	]}`
+	rs := new(resourceList)
+	if err := json.NewDecoder(strings.NewReader(body)).Decode(rs); err != nil {
+		t.Fatal(err)
+	}
+	if len(rs.Resources) != 2 {
+		t.Fatalf("expect 2 resources, got %d", len(rs.Resources))
+	}
+	if tags := strings.Join(rs.Resources[0].Tags, ","); tags != "brand,hero" {
+		t.Errorf("wrong tags. Expect brand,hero, got %s", tags)
+	}
+	if len(rs.Resources[1].Tags) != 0 {
+		t.Errorf("expect no tags, got %v", rs.Resources[1].Tags)
+	}
+}
+
+func TestSimilarResources(t *testing.T) {
+	res := []*Resource{
+		{PublicId: "far", Phash: "45e637a1a05a59ba"},
+		{PublicId: "close", Phash: "ba19c8ab5fa05a5b"},
+		{PublicId: "same", Phash: "ba19c8ab5fa05a59"},
+		{PublicId: "nohash"},
+	}
+	similar := similarResources("ba19c8ab5fa05a59", res, 4)
+	if len(similar) != 2 {
+		t.Fatalf("expect 2 similar resources, got %d", len(similar))
+	}
+	if similar[0].PublicId != "same" || similar[1].PublicId != "close" {
+		t.Errorf("similar resources should be sorted by distance, got %s, %s", similar[0].PublicId, similar[1].PublicId)
+	}
+}
+
+func TestResourcesByIDs(t *testing.T) {
+	requests := 0
+	s, ts := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path != "/v1_1/cloudname/resources/raw/upload" {
+			t.Errorf("wrong path %s", r.URL.Path)
+		}
+		ids := r.URL.Query()["public_ids[]"]
+		if len(ids) > maxPublicIds {
+			t.Errorf("too many ids in a single request: %d", len(ids))
+		}
+		rs := new(resourceList)
+		for _, id := range ids {
+			if id == "missing" {
+				continue
+			}
+			rs.Resources = append(rs.Resources, &Resource{PublicId: id})
+		}
+		json.NewEncoder(w).Encode(rs)
+	}))
+	defer ts.Close()
+
+	ids := make([]string, 0)
+	for i := 0; i < 250; i++ {
+		ids = append(ids, fmt.Sprintf("js/%d.js", i))
+	}
+	ids = append(ids, "missing")
+	res, err := s.ResourcesByIDs(ids, RawType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if requests != 3 {
+		t.Errorf("expect 3 requests for %d ids, got %d", len(ids), requests)
+	}
+	if len(res) != 250 {
+		t.Errorf("expect 250 resources, got %d", len(res))
+	}
+}
+
+func TestExistMany(t *testing.T) {
+	var batches []int
+	s, ts := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ids := r.URL.Query()["public_ids[]"]
+		batches = append(batches, len(ids))
+		rs := new(resourceList)
+		for _, id := range ids {
+			// Odd ids are absent
+			if n, _ := strconv.Atoi(strings.TrimPrefix(id, "img/")); n%2 == 0 {
+				rs.Resources = append(rs.Resources, &Resource{PublicId: id})
+			}
+		}
+		json.NewEncoder(w).Encode(rs)
+	}))
+	defer ts.Close()
+
+	ids := make([]string, 0)
+	for i := 0; i < 201; i++ {
+		ids = append(ids, fmt.Sprintf("img/%d", i))
+	}
+	exist, err := s.ExistMany(ids, ImageType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(batches) != "[100 100 1]" {
+		t.Errorf("expect batches of 100, 100 and 1 ids, got %v", batches)
+	}
+	if len(exist) != len(ids) {
+		t.Fatalf("expect %d ids, got %d", len(ids), len(exist))
+	}
+	for i, id := range ids {
+		if exist[id] != (i%2 == 0) {
+			t.Errorf("%s: expect present %v, got %v", id, i%2 == 0, exist[id])
+		}
+	}
+}
+
+func TestRateLimitStatus(t *testing.T) {
+	throttled := false
+	s, ts := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1_1/cloudname/ping" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		w.Header().Set("X-FeatureRateLimit-Limit", "500")
+		w.Header().Set("X-FeatureRateLimit-Remaining", "498")
+		w.Header().Set("X-FeatureRateLimit-Reset", "1500003600")
+		if throttled {
+			w.Header().Set("X-FeatureRateLimit-Remaining", "0")
+			w.Header().Set("X-FeatureRateLimit-Reset", "Fri, 14 Jul 2017 03:40:00 GMT")
+			w.WriteHeader(420)
+			fmt.Fprint(w, `{"error":{"message":"Rate Limit Exceeded"}}`)
+			return
+		}
+		fmt.Fprint(w, `{"status":"ok"}`)
+	}))
+	defer ts.Close()
+
+	rl, err := s.RateLimitStatus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rl.Limit != 500 || rl.Remaining != 498 || !rl.Reset.Equal(time.Unix(1500003600, 0)) {
+		t.Errorf("wrong rate limit %+v", rl)
+	}
+	// Throttled requests still report when to resume
+	throttled = true
+	rl, err = s.RateLimitStatus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rl.Remaining != 0 || !rl.Reset.Equal(time.Unix(1500003600, 0)) {
+		t.Errorf("wrong throttled rate limit %+v", rl)
+	}
+
+	for _, h := range []map[string]string{
+		{},
+		{"X-FeatureRateLimit-Limit": "500", "X-FeatureRateLimit-Remaining": "x", "X-FeatureRateLimit-Reset": "1"},
+		{"X-FeatureRateLimit-Limit": "500", "X-FeatureRateLimit-Remaining": "1", "X-FeatureRateLimit-Reset": "soon"},
+	} {
+		header := http.Header{}
+		for k, v := range h {
+			header.Set(k, v)
+		}
+		if _, err := parseRateLimit(header); err == nil {
+			t.Errorf("%v should be rejected", h)
+		}
+	}
+}
+
+func TestResourcesWithoutTag(t *testing.T) {
+	pages := []string{
+		`{"resources":[{"public_id":"a","tags":["hero"]},{"public_id":"b","tags":[]}],"next_cursor":"c1"}`,
+		`{"resources":[{"public_id":"c","tags":["brand"]},{"public_id":"d","tags":["brand","hero"]}]}`,
+	}
+	s, ts := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("tags") != "true" {
+			t.Error("tags should be requested")
+		}
+		if r.URL.Query().Get("next_cursor") == "c1" {
+			fmt.Fprint(w, pages[1])
+			return
+		}
+		fmt.Fprint(w, pages[0])
+	}))
+	defer ts.Close()
+
+	res, err := s.ResourcesWithoutTag("hero", ImageType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := make([]string, 0)
+	for _, r := range res {
+		ids = append(ids, r.PublicId)
+	}
+	if strings.Join(ids, ",") != "b,c" {
+		t.Errorf("wrong untagged resources. Expect b,c, got %s", strings.Join(ids, ","))
+	}
+}
+
+func TestListProgress(t *testing.T) {
+	s, ts := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("next_cursor") {
+		case "":
+			fmt.Fprint(w, `{"resources":[{"public_id":"a"},{"public_id":"b"}],"next_cursor":"c1"}`)
+		case "c1":
+			fmt.Fprint(w, `{"resources":[{"public_id":"c"}],"next_cursor":"c2"}`)
+		default:
+			fmt.Fprint(w, `{"resources":[{"public_id":"d"}]}`)
+		}
+	}))
+	defer ts.Close()
+
+	updates := make([]string, 0)
+	s.ListProgress(func(fetched int, done bool) {
+		updates = append(updates, fmt.Sprintf("%d:%v", fetched, done))
+	})
+	if _, err := s.Resources(ImageType); err != nil {
+		t.Fatal(err)
+	}
+	if u := strings.Join(updates, ","); u != "2:false,3:false,4:true" {
+		t.Errorf("expect one update per page, got %s", u)
+	}
+}
+
+func TestResourcesPage(t *testing.T) {
+	ids := []string{"a", "b", "c", "d", "e"}
+	requests := 0
+	s, ts := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		start, _ := strconv.Atoi(r.URL.Query().Get("next_cursor"))
+		// Two resources per page at most
+		n, _ := strconv.Atoi(r.URL.Query().Get("max_results"))
+		if n > 2 {
+			n = 2
+		}
+		end := start + n
+		if end > len(ids) {
+			end = len(ids)
+		}
+		rl := resourceList{Resources: make([]*Resource, 0)}
+		for _, id := range ids[start:end] {
+			rl.Resources = append(rl.Resources, &Resource{PublicId: id})
+		}
+		if end < len(ids) {
+			rl.NextCursor = strconv.Itoa(end)
+		}
+		json.NewEncoder(w).Encode(rl)
+	}))
+	defer ts.Close()
+
+	tests := []struct {
+		cursor   string
+		max      int
+		expect   string
+		next     string
+		requests int
+	}{
+		{"", 3, "a,b,c", "3", 2},
+		{"3", 3, "d,e", "", 1},
+		{"", 2, "a,b", "2", 1},
+		{"1", 0, "b,c,d,e", "", 2},
+	}
+	for _, tt := range tests {
+		requests = 0
+		res, next, err := s.ResourcesPage(ImageType, tt.cursor, tt.max)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := make([]string, 0, len(res))
+		for _, r := range res {
+			got = append(got, r.PublicId)
+		}
+		if g := strings.Join(got, ","); g != tt.expect || next != tt.next || requests != tt.requests {
+			t.Errorf("cursor %q, max %d: expect %s (next %q) in %d requests, got %s (next %q) in %d requests",
+				tt.cursor, tt.max, tt.expect, tt.next, tt.requests, g, next, requests)
+		}
+	}
+	if _, _, err := s.ResourcesPage(ImageType, "", -1); err == nil {
+		t.Error("negative max results should be rejected")
+	}
+}
+
+func TestResourcesByPrefix(t *testing.T) {
+	var path, prefix string
+	s, ts := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, prefix = r.URL.Path, r.URL.Query().Get("prefix")
+		fmt.Fprint(w, `{"resources":[{"public_id":"products/a"}]}`)
+	}))
+	defer ts.Close()
+
+	res, err := s.ResourcesByPrefix("products/", VideoType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != "/v1_1/cloudname/resources/video/upload" || prefix != "products/" {
+		t.Errorf("wrong request %s?prefix=%s", path, prefix)
+	}
+	if len(res) != 1 || res[0].PublicId != "products/a" {
+		t.Errorf("wrong resources %+v", res)
+	}
+}
+
+func TestResourcesFields(t *testing.T) {
+	var query url.Values
+	s, ts := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		fmt.Fprint(w, `{"resources":[{"public_id":"a","bytes":120}]}`)
+	}))
+	defer ts.Close()
+
+	res, err := s.ResourcesFields(RawType, []string{"public_id", "bytes"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if query.Get("fields") != "public_id,bytes" || query.Get("tags") != "false" {
+		t.Errorf("wrong query %v", query)
+	}
+	if len(res) != 1 || res[0].Size != 120 {
+		t.Errorf("wrong resources %+v", res)
+	}
+	if _, err := s.ResourcesFields(RawType, []string{"tags"}); err != nil || query.Get("tags") != "true" {
+		t.Errorf("tags should be requested: %v %v", query, err)
+	}
+	for _, fields := range [][]string{nil, {"public_id", "size"}} {
+		if _, err := s.ResourcesFields(RawType, fields); err == nil {
+			t.Errorf("%v should be rejected", fields)
+		}
+	}
+}
+
+func TestRawResourceDetails(t *testing.T) {
+	var path string
+	s, ts := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		fmt.Fprint(w, `{"public_id":"js/app.js","version":1,"resource_type":"raw","bytes":2048}`)
+	}))
+	defer ts.Close()
+
+	d, err := s.RawResourceDetails("js/app.js")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != "/v1_1/cloudname/resources/raw/upload/js/app.js" {
+		t.Errorf("wrong details path %s", path)
+	}
+	if d.ResourceType != "raw" || d.Size != 2048 || d.Width != 0 || d.Format != "" {
+		t.Errorf("wrong raw details %+v", d)
+	}
+}
+
+func TestColors(t *testing.T) {
+	s, ts := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1_1/cloudname/resources/image/upload/logo" || r.URL.Query().Get("colors") != "true" {
+			t.Errorf("wrong request %s", r.URL)
+		}
+		fmt.Fprint(w, `{"public_id":"logo","colors":[["#FFFFFF",62.5],["#E30613",37.5]]}`)
+	}))
+	defer ts.Close()
+
+	colors, err := s.Colors("logo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(colors) != 2 || colors[0] != (Color{"#FFFFFF", 62.5}) || colors[1] != (Color{"#E30613", 37.5}) {
+		t.Errorf("wrong colors %v", colors)
+	}
+}
+
+const versionsJSON = `{"public_id":"logo","version":1700000000,"versions":[
+	{"version_id":"v-cur","version":"1700000000","size":120,"time":"2023-11-14T22:13:20+00:00","restorable":false},
+	{"version_id":"v-old","version":"1699999999","size":100,"time":"2023-11-14T22:13:19+00:00","restorable":true}]}`
+
+func TestVersions(t *testing.T) {
+	s, ts := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1_1/cloudname/resources/image/upload/logo" || r.URL.Query().Get("versions") != "true" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+		}
+		fmt.Fprint(w, versionsJSON)
+	}))
+	defer ts.Close()
+
+	vs, err := s.Versions("logo", ImageType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []VersionInfo{
+		{Version: 1699999999, VersionId: "v-old", Size: 100, Time: time.Unix(1699999999, 0), Restorable: true},
+		{Version: 1700000000, VersionId: "v-cur", Size: 120, Time: time.Unix(1700000000, 0), Current: true},
+	}
+	if len(vs) != len(want) {
+		t.Fatalf("got %d versions, expect %d", len(vs), len(want))
+	}
+	for i := range want {
+		if !vs[i].Time.Equal(want[i].Time) {
+			t.Errorf("version %d: wrong time %v", i, vs[i].Time)
+		}
+		vs[i].Time = want[i].Time
+		if vs[i] != want[i] {
+			t.Errorf("version %d: got %+v, expect %+v", i, vs[i], want[i])
+		}
+	}
+}
+
+func TestRestoreVersion(t *testing.T) {
+	var restored url.Values
+	s, ts := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/v1_1/cloudname/resources/image/upload/logo":
+			fmt.Fprint(w, versionsJSON)
+		case r.Method == "POST" && r.URL.Path == "/v1_1/cloudname/resources/image/upload/restore":
+			r.ParseForm()
+			restored = r.PostForm
+			fmt.Fprint(w, `{"logo":{"public_id":"logo","version":1700000001}}`)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer ts.Close()
+
+	if err := s.RestoreVersion("logo", 1699999999, ImageType); err != nil {
+		t.Fatal(err)
+	}
+	if restored.Get("public_ids[]") != "logo" || restored.Get("versions[]") != "v-old" {
+		t.Errorf("wrong restore parameters %v", restored)
+	}
+
+	restored = nil
+	s.Simulate(true)
+	if err := s.RestoreVersion("logo", 1699999999, ImageType); err != nil {
+		t.Fatal(err)
+	}
+	if restored != nil {
+		t.Errorf("a simulated restore should not be sent, got %v", restored)
+	}
+	s.Simulate(false)
+
+	if err := s.RestoreVersion("logo", 1700000000, ImageType); err == nil {
+		t.Error("restoring the current version should fail")
+	}
+	if err := s.RestoreVersion("logo", 42, ImageType); err == nil {
+		t.Error("restoring an unknown version should fail")
+	}
+}
@@ -0,0 +1,143 @@
+// Copyright 2013 Mathias Monnerville and Anthony Baillard.
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cloudinary
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// Upper bound of the delay between two upload status checks
+	maxPollInterval = 30 * time.Second
+)
+
+// Clock functions, replaced in tests
+var (
+	timeNow   = time.Now
+	timeSleep = time.Sleep
+)
+
+// jobToken returns the token identifying an asynchronous upload, e.g.
+// raw:js/app.js. The version replaced by the upload, if any, is part of
+// it, e.g. raw@1500000000:js/app.js, so that the upload isn't seen
+// complete until a newer version is stored.
+func jobToken(rtype ResourceType, publicId string, replaced int) string {
+	kind := resourceTypeName(rtype)
+	if replaced > 0 {
+		kind += "@" + strconv.Itoa(replaced)
+	}
+	return kind + ":" + publicId
+}
+
+// parseJobToken returns the resource type, public id and replaced
+// version of an asynchronous upload token.
+func parseJobToken(token string) (ResourceType, string, int, error) {
+	idx := strings.Index(token, ":")
+	if idx == -1 || idx == len(token)-1 {
+		return ImageType, "", 0, fmt.Errorf("invalid job token %q", token)
+	}
+	kind, replaced := token[:idx], 0
+	if at := strings.Index(kind, "@"); at != -1 {
+		v, err := strconv.Atoi(kind[at+1:])
+		if err != nil || v <= 0 {
+			return ImageType, "", 0, fmt.Errorf("invalid job token %q", token)
+		}
+		kind, replaced = kind[:at], v
+	}
+	switch kind {
+	case imageType, videoType, rawType:
+		return resourceTypeFromName(kind), token[idx+1:], replaced, nil
+	}
+	return ImageType, "", 0, fmt.Errorf("invalid job token %q", token)
+}
+
+// currentVersion returns the version of the publicId resource, zero if
+// there is none.
+func (s *Service) currentVersion(publicId string, rtype ResourceType) (int, error) {
+	s.detailsCache.evict(publicId)
+	details, err := s.doGetResourceDetails(publicId, rtype, nil)
+	if isNotFound(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return details.Version, nil
+}
+
+// UploadStatus checks whether the asynchronous upload identified by
+// token has completed, add-ons processing included. If done, the
+// uploaded resource is returned. An upload replacing a resource is
+// only complete once a newer version is stored.
+func (s *Service) UploadStatus(token string) (done bool, result *Resource, err error) {
+	rtype, publicId, replaced, err := parseJobToken(token)
+	if err != nil {
+		return false, nil, err
+	}
+	// Polled until the status changes, never answered by the cache
+	s.detailsCache.evict(publicId)
+	details, err := s.doGetResourceDetails(publicId, rtype, nil)
+	if err != nil {
+		if isNotFound(err) {
+			return false, nil, nil
+		}
+		return false, nil, err
+	}
+	if details.Version <= replaced || details.Info.pending() {
+		return false, nil, nil
+	}
+	return true, details.resource(), nil
+}
+
+// WaitUpload polls the status of the asynchronous upload identified by
+// token until it completes. The delay between two checks starts at
+// interval and doubles after each check, up to 30 seconds. An error is
+// returned if the upload is still pending after timeout; a zero timeout
+// waits forever.
+func (s *Service) WaitUpload(token string, interval, timeout time.Duration) (*Resource, error) {
+	if interval <= 0 {
+		return nil, errors.New("poll interval must be positive")
+	}
+	start := timeNow()
+	for {
+		done, res, err := s.UploadStatus(token)
+		if err != nil {
+			return nil, err
+		}
+		if done {
+			return res, nil
+		}
+		if timeout > 0 && timeNow().Sub(start)+interval > timeout {
+			return nil, fmt.Errorf("upload %s still pending after %s", token, timeout)
+		}
+		timeSleep(interval)
+		if interval *= 2; interval > maxPollInterval {
+			interval = maxPollInterval
+		}
+	}
+}
+
+// resource returns the resource described by the details.
+func (d *ResourceDetails) resource() *Resource {
+	return &Resource{
+		PublicId:     d.PublicId,
+		Format:       d.Format,
+		Version:      d.Version,
+		ResourceType: d.ResourceType,
+		Size:         d.Size,
+		Width:        d.Width,
+		Height:       d.Height,
+		Url:          d.Url,
+		SecureUrl:    d.SecureUrl,
+		Tags:         d.Tags,
+		Phash:        d.Phash,
+		Info:         d.Info,
+	}
+}
@@ -0,0 +1,212 @@
+// Copyright 2013 Mathias Monnerville and Anthony Baillard.
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+package cloudinary
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeClock replaces the package clock, sleeping moves it forward.
+func fakeClock(t *testing.T) *[]time.Duration {
+	now := time.Unix(1500000000, 0)
+	sleeps := make([]time.Duration, 0)
+	timeNow = func() time.Time { return now }
+	timeSleep = func(d time.Duration) {
+		sleeps = append(sleeps, d)
+		now = now.Add(d)
+	}
+	return &sleeps
+}
+
+func restoreClock() {
+	timeNow, timeSleep = time.Now, time.Sleep
+}
+
+func TestJobToken(t *testing.T) {
+	for _, replaced := range []int{0, 1500000000} {
+		token := jobToken(RawType, "js/app.js", replaced)
+		rtype, id, version, err := parseJobToken(token)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rtype != RawType || id != "js/app.js" || version != replaced {
+			t.Errorf("wrong token %s decoding: %v %s %d", token, rtype, id, version)
+		}
+	}
+	for _, bad := range []string{"", "image", "image:", "pdf:x", "raw@:x", "raw@x:y", "raw@0:x"} {
+		if _, _, _, err := parseJobToken(bad); err == nil {
+			t.Errorf("%q should be an invalid token", bad)
+		}
+	}
+}
+
+func TestAsyncUpload(t *testing.T) {
+	checks := 0
+	uploaded := false
+	s, ts := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/image/upload/") {
+			if r.FormValue("async") != "true" {
+				t.Error("missing async parameter")
+			}
+			uploaded = true
+			fmt.Fprint(w, `{"status":"pending"}`)
+			return
+		}
+		if r.URL.Path != "/v1_1/cloudname/resources/image/upload/images/logo" {
+			t.Errorf("wrong status path %s", r.URL.Path)
+		}
+		if uploaded {
+			checks++
+		}
+		if checks < 3 {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"error":{"message":"Resource not found - images/logo"}}`)
+			return
+		}
+		fmt.Fprint(w, `{"public_id":"images/logo","version":2,"resource_type":"image","bytes":3}`)
+	}))
+	defer ts.Close()
+	sleeps := fakeClock(t)
+	defer restoreClock()
+
+	if _, err := s.UploadWithOptions("logo.png", strings.NewReader("png"), "images", true, ImageType, &UploadOptions{Async: true}); err == nil {
+		t.Error("asynchronous uploads with a random public id should fail")
+	}
+	res, err := s.UploadWithOptions("logo.png", strings.NewReader("png"), "images", false, ImageType, &UploadOptions{Async: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Status != "pending" || res.JobToken != "image:images/logo" {
+		t.Fatalf("wrong pending resource %+v", res)
+	}
+	done, err := s.WaitUpload(res.JobToken, time.Second, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if done.PublicId != "images/logo" || done.Version != 2 {
+		t.Errorf("wrong uploaded resource %+v", done)
+	}
+	if len(*sleeps) != 2 || (*sleeps)[0] != time.Second || (*sleeps)[1] != 2*time.Second {
+		t.Errorf("expect 1s then 2s backoff, got %v", *sleeps)
+	}
+}
+
+func TestAsyncReupload(t *testing.T) {
+	version := 1
+	s, ts := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/raw/upload/") {
+			fmt.Fprint(w, `{"status":"pending"}`)
+			return
+		}
+		fmt.Fprintf(w, `{"public_id":"ci/build","version":%d,"resource_type":"raw"}`, version)
+	}))
+	defer ts.Close()
+	fakeClock(t)
+	defer restoreClock()
+
+	res, err := s.UploadWithOptions("build.zip", strings.NewReader("zip"), "ci/", false, RawType, &UploadOptions{Async: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.JobToken != "raw@1:ci/build" {
+		t.Fatalf("expect the replaced version in the job token, got %s", res.JobToken)
+	}
+	// The previous version is still stored
+	if done, _, err := s.UploadStatus(res.JobToken); err != nil || done {
+		t.Errorf("expect the upload to be pending, got %v (%v)", done, err)
+	}
+	version = 2
+	done, up, err := s.UploadStatus(res.JobToken)
+	if err != nil || !done || up.Version != 2 {
+		t.Errorf("expect the new version, got %v %+v (%v)", done, up, err)
+	}
+}
+
+func TestWaitUploadPolling(t *testing.T) {
+	var checks, completeAt int
+	s, ts := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		checks++
+		if completeAt == 0 || checks < completeAt {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"error":{"message":"Resource not found - ci/build"}}`)
+			return
+		}
+		fmt.Fprint(w, `{"public_id":"ci/build","version":3,"resource_type":"raw"}`)
+	}))
+	defer ts.Close()
+
+	tests := []struct {
+		completeAt int
+		timeout    time.Duration
+		sleeps     []time.Duration
+		done       bool
+	}{
+		// Complete at the fourth check, the interval doubles up to 30s
+		{4, 5 * time.Minute, []time.Duration{2 * time.Second, 4 * time.Second, 8 * time.Second}, true},
+		// Never completes: the check due after the timeout isn't waited for
+		{0, 20 * time.Second, []time.Duration{2 * time.Second, 4 * time.Second, 8 * time.Second}, false},
+		{0, 40 * time.Second, []time.Duration{2 * time.Second, 4 * time.Second, 8 * time.Second, 16 * time.Second}, false},
+	}
+	for _, tt := range tests {
+		checks, completeAt = 0, tt.completeAt
+		sleeps := fakeClock(t)
+		res, err := s.WaitUpload("raw:ci/build", 2*time.Second, tt.timeout)
+		restoreClock()
+		if tt.done && (err != nil || res.Version != 3) {
+			t.Errorf("expect the uploaded resource, got %+v (%v)", res, err)
+		}
+		if !tt.done && (err == nil || !strings.Contains(err.Error(), "still pending after "+tt.timeout.String())) {
+			t.Errorf("expect a timeout after %s, got %v", tt.timeout, err)
+		}
+		if fmt.Sprint(*sleeps) != fmt.Sprint(tt.sleeps) {
+			t.Errorf("expect sleeps %v, got %v", tt.sleeps, *sleeps)
+		}
+	}
+	if _, err := s.WaitUpload("raw:ci/build", 0, 0); err == nil {
+		t.Error("a zero poll interval should be rejected")
+	}
+}
+
+func TestBackgroundRemoval(t *testing.T) {
+	checks := 0
+	s, ts := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/image/upload/") {
+			if v := r.FormValue("background_removal"); v != "cloudinary_ai" {
+				t.Errorf("expect background_removal=cloudinary_ai, got %q", v)
+			}
+			fmt.Fprint(w, `{"public_id":"products/shoe","version":1,"info":{"background_removal":{"cloudinary_ai":{"status":"pending"}}}}`)
+			return
+		}
+		checks++
+		status := "pending"
+		if checks == 2 {
+			status = "complete"
+		}
+		fmt.Fprintf(w, `{"public_id":"products/shoe","version":2,"info":{"background_removal":{"cloudinary_ai":{"status":%q}}}}`, status)
+	}))
+	defer ts.Close()
+	fakeClock(t)
+	defer restoreClock()
+
+	opts := &UploadOptions{BackgroundRemoval: "cloudinary_ai"}
+	res, err := s.UploadWithOptions("shoe.png", strings.NewReader("png"), "products", false, ImageType, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Status != "pending" || res.JobToken != "image:products/shoe" {
+		t.Fatalf("wrong pending resource %+v", res)
+	}
+	done, err := s.WaitUpload(res.JobToken, time.Second, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if checks != 2 || done.Version != 2 || done.Info.BackgroundRemoval["cloudinary_ai"].Status != "complete" {
+		t.Errorf("wrong processed resource %+v after %d checks", done, checks)
+	}
+}
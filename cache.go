@@ -0,0 +1,104 @@
+// Copyright 2013 Mathias Monnerville and Anthony Baillard.
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cloudinary
+
+import (
+	"encoding/json"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// detailsCache keeps resource details in memory for a while, to save
+// admin API calls when the same resources are looked up repeatedly. It
+// is safe for concurrent use.
+type detailsCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[detailsKey]detailsEntry
+}
+
+type detailsKey struct {
+	publicId string
+	rtype    ResourceType
+	query    string // Extra details requested
+}
+
+// detailsEntry holds the JSON encoded details, so that each hit decodes
+// its own copy: callers can't change the cached details through the
+// slices and maps of their copy.
+type detailsEntry struct {
+	details []byte
+	expires time.Time
+}
+
+// SetDetailsCache caches the resource details fetched by the admin API,
+// e.g. by ResourceDetails(), for ttl. Entries are evicted when their
+// resource is uploaded, deleted, renamed or updated through the
+// service; changes made elsewhere are only seen after ttl. Exists() and
+// UploadStatus(), which are polled for changes, always send a request.
+// A zero ttl disables the cache.
+func (s *Service) SetDetailsCache(ttl time.Duration) {
+	if ttl <= 0 {
+		s.detailsCache = nil
+		return
+	}
+	s.detailsCache = &detailsCache{ttl: ttl, entries: make(map[detailsKey]detailsEntry)}
+}
+
+// get returns a copy of the cached details, nil if missing or expired.
+func (c *detailsCache) get(publicId string, rtype ResourceType, qs url.Values) *ResourceDetails {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	k := detailsKey{publicId, rtype, qs.Encode()}
+	e, ok := c.entries[k]
+	if !ok {
+		return nil
+	}
+	if !timeNow().Before(e.expires) {
+		delete(c.entries, k)
+		return nil
+	}
+	d := new(ResourceDetails)
+	if err := json.Unmarshal(e.details, d); err != nil {
+		return nil
+	}
+	return d
+}
+
+func (c *detailsCache) put(publicId string, rtype ResourceType, qs url.Values, details *ResourceDetails) {
+	if c == nil {
+		return
+	}
+	b, err := json.Marshal(details)
+	if err != nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[detailsKey{publicId, rtype, qs.Encode()}] = detailsEntry{b, timeNow().Add(c.ttl)}
+}
+
+// evict removes the cached details of the publicIds resources, whatever
+// their type and the extra details requested.
+func (c *detailsCache) evict(publicIds ...string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for k := range c.entries {
+		for _, id := range publicIds {
+			if k.publicId == id {
+				delete(c.entries, k)
+				break
+			}
+		}
+	}
+}
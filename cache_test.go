@@ -0,0 +1,153 @@
+// Copyright 2013 Mathias Monnerville and Anthony Baillard.
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+package cloudinary
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDetailsCache(t *testing.T) {
+	lookups := 0
+	s, ts := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/upload/"), strings.HasSuffix(r.URL.Path, "/destroy/"):
+			fmt.Fprint(w, `{"public_id":"images/logo","version":2,"result":"ok"}`)
+		default:
+			lookups++
+			fmt.Fprint(w, `{"public_id":"images/logo","version":1}`)
+		}
+	}))
+	defer ts.Close()
+	fakeClock(t)
+	defer restoreClock()
+	s.SetDetailsCache(time.Minute)
+
+	lookup := func(expect int) {
+		t.Helper()
+		if _, err := s.ResourceDetails("images/logo"); err != nil {
+			t.Fatal(err)
+		}
+		if lookups != expect {
+			t.Errorf("expect %d lookups, got %d", expect, lookups)
+		}
+	}
+	lookup(1)
+	lookup(1)
+	// Details requested with other options are not shared
+	if _, err := s.Versions("images/logo", ImageType); err != nil {
+		t.Fatal(err)
+	}
+	lookup(2)
+
+	timeSleep(time.Minute)
+	lookup(3)
+
+	if _, err := s.UploadWithOptions("logo.png", strings.NewReader("png"), "images/", false, ImageType, nil); err != nil {
+		t.Fatal(err)
+	}
+	lookup(4)
+	lookup(4)
+	if _, err := s.Delete("logo", "images/", ImageType, false); err != nil {
+		t.Fatal(err)
+	}
+	lookup(5)
+	// The folder of a preserved structure is part of the evicted id
+	opts := &UploadOptions{PreserveStructure: true}
+	if _, err := s.UploadWithOptions("images/logo.png", strings.NewReader("png"), "", false, ImageType, opts); err != nil {
+		t.Fatal(err)
+	}
+	lookup(6)
+
+	s.SetDetailsCache(0)
+	lookup(7)
+	lookup(8)
+}
+
+func TestDetailsCachePolling(t *testing.T) {
+	lookups := 0
+	s, ts := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lookups++
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/logo") && lookups > 2:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"error":{"message":"Resource not found - logo"}}`)
+		case strings.HasSuffix(r.URL.Path, "/logo"):
+			fmt.Fprint(w, `{"public_id":"logo","version":1}`)
+		case lookups > 2:
+			fmt.Fprint(w, `{"public_id":"ci/build","version":3,"resource_type":"raw"}`)
+		default:
+			fmt.Fprint(w, `{"public_id":"ci/build","version":3,"resource_type":"raw","info":{"background_removal":{"cloudinary_ai":{"status":"pending"}}}}`)
+		}
+	}))
+	defer ts.Close()
+	fakeClock(t)
+	defer restoreClock()
+	s.SetDetailsCache(time.Hour)
+
+	// Still listed on the first checks only
+	if _, err := s.ResourceDetails("logo"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.VerifyDeleted("logo", ImageType); err != nil {
+		t.Errorf("expect the deletion to be seen despite the cache, got %v", err)
+	}
+
+	lookups = 0
+	if _, _, err := s.UploadStatus("raw:ci/build"); err != nil {
+		t.Fatal(err)
+	}
+	res, err := s.WaitUpload("raw:ci/build", time.Second, time.Minute)
+	if err != nil || res.Version != 3 {
+		t.Errorf("expect the completed upload despite the cache, got %+v (%v)", res, err)
+	}
+}
+
+func TestDetailsCacheCopies(t *testing.T) {
+	body := `{"public_id":"images/logo","version":1,"tags":["brand"],
+		"context":{"custom":{"alt":"Logo"}},"metadata":{"sku":"A1","sizes":["s","m"]},
+		"coordinates":{"face":[[1,2,3,4]]},"versions":[{"version_id":"v1","version":"1"}],
+		"derived":[{"id":"d1","transformation":"w_100"}],"colors":[["#1C2A3F",42.5]],
+		"info":{"background_removal":{"cloudinary_ai":{"status":"complete"}}}}`
+	lookups := 0
+	s, ts := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lookups++
+		fmt.Fprint(w, body)
+	}))
+	defer ts.Close()
+	s.SetDetailsCache(time.Minute)
+	want := new(ResourceDetails)
+	if err := json.Unmarshal([]byte(body), want); err != nil {
+		t.Fatal(err)
+	}
+
+	// Changing the returned details leaves the cached ones intact
+	for i := 0; i < 3; i++ {
+		d, err := s.ResourceDetails("images/logo")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(d, want) {
+			t.Fatalf("lookup %d: expect %+v, got %+v", i, want, d)
+		}
+		d.Tags[0] = "changed"
+		d.Context.Custom["alt"] = "changed"
+		d.Metadata["sku"] = "changed"
+		d.Metadata["sizes"].([]interface{})[0] = "changed"
+		d.Coordinates.Face[0][0] = 0
+		d.Versions[0].VersionId = "changed"
+		d.Derived[0].Id = "changed"
+		d.Colors[0].Hex = "changed"
+		d.Info.BackgroundRemoval["cloudinary_ai"].Status = "changed"
+	}
+	if lookups != 1 {
+		t.Errorf("expect a single lookup, got %d", lookups)
+	}
+}
@@ -0,0 +1,46 @@
+// Copyright 2013 Mathias Monnerville and Anthony Baillard.
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cloudinary
+
+import (
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// SetClockSkewTolerance corrects the drift of the local clock, up to d.
+// Cloudinary rejects signed requests whose timestamp is more than one
+// hour off. When an upload is rejected as stale, the offset between the
+// local clock and the server clock, read from the Date header of the
+// response, is recorded and applied to the timestamps of all signed
+// requests. The upload is then retried once. Offsets greater than d are
+// not corrected. Zero, the default, disables the correction.
+func (s *Service) SetClockSkewTolerance(d time.Duration) {
+	s.skewTolerance = d
+}
+
+// now returns the local time, corrected by the recorded clock offset.
+func (s *Service) now() time.Time {
+	return timeNow().Add(time.Duration(atomic.LoadInt64(&s.clockOffset)))
+}
+
+// correctClock records the clock offset if err reports a stale request
+// and the offset is within the tolerance. It returns true if the
+// request should be signed and sent again.
+func (s *Service) correctClock(err error) bool {
+	e, ok := err.(*APIError)
+	if !ok || s.skewTolerance <= 0 || e.ServerTime.IsZero() || !strings.Contains(e.Message, "Stale request") {
+		return false
+	}
+	offset := e.ServerTime.Sub(timeNow())
+	if offset > s.skewTolerance || -offset > s.skewTolerance {
+		s.logger.Printf("Clock skew of %s over the %s tolerance, not corrected\n", offset, s.skewTolerance)
+		return false
+	}
+	s.logger.Printf("Stale request, correcting a clock skew of %s\n", offset)
+	atomic.StoreInt64(&s.clockOffset, int64(offset))
+	return true
+}
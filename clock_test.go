@@ -0,0 +1,62 @@
+// Copyright 2013 Mathias Monnerville and Anthony Baillard.
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+package cloudinary
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClockSkewCorrection(t *testing.T) {
+	fakeClock(t)
+	defer restoreClock()
+	// The local clock is two hours late
+	serverNow := timeNow().Add(2 * time.Hour)
+	attempts := 0
+	s, ts := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		r.ParseMultipartForm(1 << 20)
+		stamp, _ := strconv.ParseInt(r.PostForm.Get("timestamp"), 10, 64)
+		w.Header().Set("Date", serverNow.UTC().Format(http.TimeFormat))
+		if d := serverNow.Sub(time.Unix(stamp, 0)); d > time.Hour || d < -time.Hour {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprintf(w, `{"error":{"message":"Stale request - reported time is %s which is more than 1 hour ago"}}`, time.Unix(stamp, 0).UTC())
+			return
+		}
+		fmt.Fprint(w, `{"public_id":"logo","version":1}`)
+	}))
+	defer ts.Close()
+
+	upload := func() error {
+		_, err := s.UploadWithOptions("logo.png", strings.NewReader("png"), "", false, ImageType, nil)
+		return err
+	}
+	// Disabled by default
+	if err := upload(); err == nil || attempts != 1 {
+		t.Fatalf("expect a stale request error in 1 attempt, got %v in %d", err, attempts)
+	}
+	attempts = 0
+	s.SetClockSkewTolerance(time.Hour)
+	if err := upload(); err == nil || attempts != 1 {
+		t.Fatalf("a skew over the tolerance should not be corrected, got %v in %d attempts", err, attempts)
+	}
+	attempts = 0
+	s.SetClockSkewTolerance(3 * time.Hour)
+	if err := upload(); err != nil || attempts != 2 {
+		t.Fatalf("expect a corrected upload in 2 attempts, got %v in %d", err, attempts)
+	}
+	// The offset is kept for the next requests
+	attempts = 0
+	if err := upload(); err != nil || attempts != 1 {
+		t.Errorf("expect a single attempt once corrected, got %v in %d", err, attempts)
+	}
+	if d := s.now().Sub(serverNow); d != 0 {
+		t.Errorf("expect the clock to be corrected, %s off", d)
+	}
+}
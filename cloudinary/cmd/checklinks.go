@@ -0,0 +1,71 @@
+// Copyright © 2017 Jimmy Song
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+
+	cloudinary "github.com/rootsongjc/cloudinary-go"
+	"github.com/spf13/cobra"
+)
+
+var optLinkPrefix string
+var optConcurrency int
+
+// checkLinksCmd represents the check-links command
+var checkLinksCmd = &cobra.Command{
+	Use:   "check-links",
+	Short: "Check that the delivery URLs of files resolve",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		rtype, err := parseResourceType(optType)
+		if err != nil {
+			fail(err.Error())
+		}
+		var res []*cloudinary.Resource
+		if optLinkPrefix != "" {
+			res, err = service.ResourcesByPrefix(optLinkPrefix, rtype)
+		} else {
+			res, err = service.Resources(rtype)
+		}
+		if err != nil {
+			perror(err)
+		}
+		step(fmt.Sprintf("Checking %d delivery URLs", len(res)))
+		broken := service.CheckLinks(res, optConcurrency)
+		for _, b := range broken {
+			fmt.Fprintf(out, "%s: %s\n", b.URL, linkFailure(b))
+		}
+		if len(broken) > 0 {
+			fail(fmt.Sprintf("%d of %d delivery URLs don't resolve.", len(broken), len(res)))
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(checkLinksCmd)
+	checkLinksCmd.Flags().StringVar(&optLinkPrefix, "prefix", "", "only check files whose public id starts with this prefix, e.g. products/")
+	checkLinksCmd.Flags().StringVar(&optType, "type", "image", "resource type: image, raw or video")
+	checkLinksCmd.Flags().IntVar(&optConcurrency, "concurrency", 8, "maximum number of concurrent requests")
+}
+
+// linkFailure describes why a delivery URL doesn't resolve.
+func linkFailure(b *cloudinary.BrokenLink) string {
+	if b.Err != nil {
+		return b.Err.Error()
+	}
+	return fmt.Sprintf("%d %s", b.StatusCode, http.StatusText(b.StatusCode))
+}
@@ -0,0 +1,73 @@
+// Copyright © 2017 Jimmy Song
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	cloudinary "github.com/rootsongjc/cloudinary-go"
+	"github.com/spf13/cobra"
+)
+
+// colorsCmd represents the colors command
+var colorsCmd = &cobra.Command{
+	Use:   "colors",
+	Short: "Print the dominant colors of an image",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if optOutput != outputTable && optOutput != outputJSON {
+			fail("--output must be table or json.")
+		}
+		if optImg == "" {
+			fail("Missing -i option.")
+		}
+		colors, err := service.Colors(composePublicID(optImg))
+		if err != nil {
+			perror(err)
+		}
+		if err := writeColors(out, colors, optOutput); err != nil {
+			perror(err)
+		}
+	},
+}
+
+// writeColors writes the palette, one color and its share per line, or
+// as a JSON array.
+func writeColors(w io.Writer, colors []cloudinary.Color, format string) error {
+	if format == outputJSON {
+		if colors == nil {
+			colors = []cloudinary.Color{}
+		}
+		b, err := json.MarshalIndent(colors, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(w, "%s\n", b)
+		return err
+	}
+	for _, c := range colors {
+		if _, err := fmt.Fprintf(w, "%-9s %5.1f%%\n", c.Hex, c.Percent); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func init() {
+	RootCmd.AddCommand(colorsCmd)
+	colorsCmd.Flags().StringVarP(&optOutput, "output", "o", outputTable, "output format: table or json")
+}
@@ -0,0 +1,145 @@
+// Copyright © 2017 Jimmy Song
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	cloudinary "github.com/rootsongjc/cloudinary-go"
+	"github.com/spf13/cobra"
+)
+
+// Public ids suggested by the completion of -i and -r are listed at
+// most once in this period, as every tab press runs a new process.
+const completionCacheTTL = 5 * time.Minute
+
+// completionCmd represents the completion command
+var completionCmd = &cobra.Command{
+	Use:   "completion [bash|zsh|fish]",
+	Short: "Print the shell completion script",
+	Long: `Print the shell completion script. The public ids of -i and -r are
+completed with the remote resources, e.g. for bash:
+
+    source <(cloudinary completion bash)`,
+	ValidArgs: []string{"bash", "zsh", "fish"},
+	Args:      cobra.ExactValidArgs(1),
+	// No configured service is needed to print the script
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {},
+	Run: func(cmd *cobra.Command, args []string) {
+		var err error
+		switch args[0] {
+		case "bash":
+			err = RootCmd.GenBashCompletion(out)
+		case "zsh":
+			err = RootCmd.GenZshCompletion(out)
+		case "fish":
+			err = RootCmd.GenFishCompletion(out, true)
+		}
+		if err != nil {
+			fail(err.Error())
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(completionCmd)
+}
+
+// completePublicIDs returns a completion function suggesting the public
+// ids of the remote resources of type rtype. Local files are suggested
+// instead for put, which uploads them.
+func completePublicIDs(rtype cloudinary.ResourceType) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if cmd.Name() == "put" {
+			return nil, cobra.ShellCompDirectiveDefault
+		}
+		if service == nil {
+			// Only the completions are to be printed
+			optQuiet = true
+			initConfig()
+		}
+		ids, err := cachedPublicIDs(rtype)
+		if err != nil {
+			cobra.CompDebugln(err.Error(), true)
+			return nil, cobra.ShellCompDirectiveError
+		}
+		var matches []string
+		for _, id := range ids {
+			if strings.HasPrefix(id, toComplete) {
+				matches = append(matches, id)
+			}
+		}
+		return matches, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// completionCache holds the public ids listed for completion.
+type completionCache struct {
+	ListedAt time.Time `json:"listed_at"`
+	Ids      []string  `json:"ids"`
+}
+
+// cachedPublicIDs returns the public ids of the resources of type
+// rtype, from the completion cache when listed less than
+// completionCacheTTL ago.
+func cachedPublicIDs(rtype cloudinary.ResourceType) ([]string, error) {
+	path, err := completionCacheFile(rtype)
+	if err != nil {
+		return nil, err
+	}
+	cache := new(completionCache)
+	if data, err := ioutil.ReadFile(path); err == nil && json.Unmarshal(data, cache) == nil {
+		if time.Since(cache.ListedAt) < completionCacheTTL {
+			return cache.Ids, nil
+		}
+	}
+	res, err := service.Resources(rtype)
+	if err != nil {
+		return nil, err
+	}
+	cache = &completionCache{ListedAt: time.Now(), Ids: make([]string, len(res))}
+	for i, r := range res {
+		cache.Ids[i] = r.PublicId
+	}
+	// The cache only saves listings, it's fine if it can't be written
+	if data, err := json.Marshal(cache); err == nil {
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err == nil {
+			ioutil.WriteFile(path, data, 0600)
+		}
+	}
+	return cache.Ids, nil
+}
+
+// completionCacheFile returns the path of the completion cache of the
+// resources of type rtype of the configured cloud, in the user cache
+// directory.
+func completionCacheFile(rtype cloudinary.ResourceType) (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	typ := "image"
+	if rtype == cloudinary.RawType {
+		typ = "raw"
+	}
+	name := fmt.Sprintf("completion-%s-%s.json", service.CloudName(), typ)
+	return filepath.Join(dir, "cloudinary", name), nil
+}
@@ -0,0 +1,81 @@
+// Copyright © 2017 Jimmy Song
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	cloudinary "github.com/rootsongjc/cloudinary-go"
+	"github.com/spf13/cobra"
+)
+
+func TestCompletePublicIDs(t *testing.T) {
+	requests := 0
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path != "/v1_1/cloudname/resources/image" {
+			t.Errorf("unexpected request %s", r.URL.Path)
+		}
+		fmt.Fprint(w, `{"resources":[{"public_id":"images/logo"},{"public_id":"images/cover"},{"public_id":"css/site"}]}`)
+	}))
+	defer ts.Close()
+	defer func(s *cloudinary.Service) { service = s }(service)
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+
+	var err error
+	if service, err = cloudinary.NewService("cloudname", "key", "secret"); err != nil {
+		t.Fatal(err)
+	}
+	service.SetHTTPClient(ts.Client())
+	if err := service.SetAPIHost(ts.Listener.Addr().String()); err != nil {
+		t.Fatal(err)
+	}
+
+	complete := completePublicIDs(cloudinary.ImageType)
+	for i := 0; i < 2; i++ {
+		ids, directive := complete(lsCmd, nil, "images/")
+		if exp := []string{"images/logo", "images/cover"}; !reflect.DeepEqual(ids, exp) {
+			t.Errorf("got %v, expect %v", ids, exp)
+		}
+		if directive != cobra.ShellCompDirectiveNoFileComp {
+			t.Errorf("wrong directive %v", directive)
+		}
+	}
+	if requests != 1 {
+		t.Errorf("expect the listing to be cached, got %d requests", requests)
+	}
+
+	var buf bytes.Buffer
+	RootCmd.SetOut(&buf)
+	defer RootCmd.SetOut(nil)
+	RootCmd.SetArgs([]string{cobra.ShellCompRequestCmd, "ls", "-i", "images/l"})
+	if err := RootCmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(buf.String(), "images/logo\n:4\n") {
+		t.Errorf("expect -i to be completed with remote ids, got %q", buf.String())
+	}
+
+	if ids, directive := complete(putCmd, nil, ""); ids != nil || directive != cobra.ShellCompDirectiveDefault {
+		t.Errorf("expect local files to be completed for put, got %v", ids)
+	}
+}
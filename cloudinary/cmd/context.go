@@ -0,0 +1,70 @@
+// Copyright © 2017 Jimmy Song
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	cloudinary "github.com/rootsongjc/cloudinary-go"
+	"github.com/spf13/cobra"
+)
+
+var optContextKey string
+var optContextImgs, optContextRaws []string
+
+// contextCmd represents the context command
+var contextCmd = &cobra.Command{
+	Use:   "context",
+	Short: "Manage the context metadata of files",
+}
+
+var contextRemoveCmd = &cobra.Command{
+	Use:   "remove",
+	Short: "Remove a context entry, or all of them, from files",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(optContextImgs) == 0 && len(optContextRaws) == 0 {
+			fail("Missing -i or -r option.")
+		}
+		if len(optContextImgs) > 0 && len(optContextRaws) > 0 {
+			fail("-i and -r can't be used together.")
+		}
+		rtype, ids := cloudinary.ImageType, optContextImgs
+		if len(optContextRaws) > 0 {
+			rtype, ids = cloudinary.RawType, optContextRaws
+		}
+		entry := "all context entries"
+		if optContextKey != "" {
+			entry = fmt.Sprintf("context entry %q", optContextKey)
+		}
+		step(fmt.Sprintf("Removing %s from %s", entry, strings.Join(ids, ", ")))
+		if optSimulate {
+			return
+		}
+		if err := service.RemoveContext(optContextKey, ids, rtype); err != nil {
+			perror(err)
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(contextCmd)
+	contextCmd.AddCommand(contextRemoveCmd)
+	contextRemoveCmd.Flags().StringVar(&optContextKey, "key", "", "context key to remove (default all keys)")
+	// Repeatable, unlike the global -i and -r
+	contextRemoveCmd.Flags().StringArrayVarP(&optContextImgs, "image", "i", nil, "public id of an image (repeatable)")
+	contextRemoveCmd.Flags().StringArrayVarP(&optContextRaws, "raw", "r", nil, "public id of a raw file (repeatable)")
+}
@@ -0,0 +1,129 @@
+// Copyright © 2017 Jimmy Song
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	cloudinary "github.com/rootsongjc/cloudinary-go"
+	"github.com/spf13/cobra"
+)
+
+// derivedCmd represents the derived command
+var derivedCmd = &cobra.Command{
+	Use:   "derived",
+	Short: "Analyze derived resources",
+}
+
+var derivedReportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "List the resources using the most derived storage",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		rtype, err := parseResourceType(optDerivedType)
+		if err != nil {
+			perror(err)
+		}
+		step("Fetching resource details, this may take a while")
+		inv, err := service.DerivedInventory(rtype)
+		if err != nil {
+			perror(err)
+		}
+		top := topDerived(inv, optTop)
+		if len(top) == 0 {
+			info("No derived resource found.")
+			return
+		}
+		fmt.Fprintf(out, "%-30s %-8s %s\n", "public_id", "Derived", "Size(KB)")
+		for _, d := range top {
+			fmt.Fprintf(out, "%-30s %-8d %d\n", d.publicID, d.Count, d.Size/1024)
+		}
+	},
+}
+
+var derivedPurgeCmd = &cobra.Command{
+	Use:   "purge",
+	Short: "Delete all derived resources, originals are kept",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := confirmPurge(optYes, optSimulate); err != nil {
+			fail(err.Error())
+		}
+		rtype, err := parseResourceType(optDerivedType)
+		if err != nil {
+			perror(err)
+		}
+		step("Fetching resource details, this may take a while")
+		count, freed, err := service.PurgeAllDerived(rtype)
+		if err != nil {
+			perror(err)
+		}
+		if optSimulate {
+			step(fmt.Sprintf("Would delete %d derived resources, freeing %d KB", count, freed/1024))
+			return
+		}
+		step(fmt.Sprintf("Deleted %d derived resources, freed %d KB", count, freed/1024))
+	},
+}
+
+var optTop int
+var optDerivedType string
+var optYes bool
+
+func init() {
+	RootCmd.AddCommand(derivedCmd)
+	derivedCmd.AddCommand(derivedReportCmd, derivedPurgeCmd)
+	derivedReportCmd.Flags().IntVar(&optTop, "top", 10, "number of resources listed, 0 for all")
+	derivedCmd.PersistentFlags().StringVar(&optDerivedType, "type", "image", "resource type: image, raw, video or pdf")
+	derivedPurgeCmd.Flags().BoolVar(&optYes, "yes", false, "confirm the deletion of all derived resources")
+}
+
+// confirmPurge returns an error unless the purge is confirmed or
+// simulated.
+func confirmPurge(yes, simulate bool) error {
+	if yes || simulate {
+		return nil
+	}
+	return errors.New("purge deletes all derived resources: confirm with --yes, or use --simulate to see what would be freed")
+}
+
+type derivedEntry struct {
+	publicID string
+	cloudinary.DerivedStats
+}
+
+// topDerived returns the n resources with the largest derived storage,
+// largest first, ignoring resources without derived resources. All of
+// them are returned if n is 0.
+func topDerived(inv map[string]cloudinary.DerivedStats, n int) []derivedEntry {
+	entries := make([]derivedEntry, 0, len(inv))
+	for id, st := range inv {
+		if st.Count > 0 {
+			entries = append(entries, derivedEntry{id, st})
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Size != entries[j].Size {
+			return entries[i].Size > entries[j].Size
+		}
+		return entries[i].publicID < entries[j].publicID
+	})
+	if n > 0 && len(entries) > n {
+		entries = entries[:n]
+	}
+	return entries
+}
@@ -0,0 +1,50 @@
+// Copyright © 2017 Jimmy Song
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"testing"
+
+	cloudinary "github.com/rootsongjc/cloudinary-go"
+)
+
+func TestTopDerived(t *testing.T) {
+	inv := map[string]cloudinary.DerivedStats{
+		"cover": {Count: 3, Size: 13000},
+		"logo":  {Count: 1, Size: 500},
+		"icon":  {},
+		"hero":  {Count: 2, Size: 500},
+	}
+	top := topDerived(inv, 2)
+	if len(top) != 2 || top[0].publicID != "cover" || top[1].publicID != "hero" {
+		t.Errorf("wrong top 2 %+v", top)
+	}
+	all := topDerived(inv, 0)
+	if len(all) != 3 || all[2].publicID != "logo" {
+		t.Errorf("resources without derived ones should be skipped, got %+v", all)
+	}
+}
+
+func TestConfirmPurge(t *testing.T) {
+	if err := confirmPurge(false, false); err == nil {
+		t.Error("purge should require --yes")
+	}
+	if err := confirmPurge(true, false); err != nil {
+		t.Errorf("--yes should confirm: %s", err)
+	}
+	if err := confirmPurge(false, true); err != nil {
+		t.Errorf("a simulation needs no confirmation: %s", err)
+	}
+}
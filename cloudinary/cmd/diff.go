@@ -0,0 +1,157 @@
+// Copyright © 2017 Jimmy Song
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	cloudinary "github.com/rootsongjc/cloudinary-go"
+	"github.com/spf13/cobra"
+)
+
+var optDeleteOrphans bool
+
+// diffCmd represents the diff command
+var diffCmd = &cobra.Command{
+	Use:   "diff <dir>",
+	Short: "Compare a local directory with remote resources",
+	Long: `Compare a local directory with remote resources.
+
+Local files are given the public ids they would get with put. When a
+prepend path is set, only remote resources below it are compared.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if optPath != "" {
+			settings.PrependPath = optPath
+		}
+		rtype, err := parseResourceType(optType)
+		if err != nil {
+			perror(err)
+		}
+		if rtype == cloudinary.AutoType {
+			fail("Can't list auto resources, use image, raw, video or pdf.")
+		}
+		local, err := localPublicIDs(args[0], settings.PrependPath)
+		if err != nil {
+			perror(err)
+		}
+		res, err := service.Resources(rtype)
+		if err != nil {
+			perror(err)
+		}
+		remote := remotePublicIDs(res, settings.PrependPath)
+		toUpload, orphans, common := diffSets(local, remote)
+
+		printSet("To upload (local only):", toUpload)
+		printSet("Orphaned (remote only):", orphans)
+		printSet("Common:", common)
+
+		if !optDeleteOrphans {
+			return
+		}
+		connectDatabase(false)
+		for _, id := range orphans {
+			if service.Protected(id) {
+				info("Keeping", id)
+				continue
+			}
+			step(fmt.Sprintf("Deleting %s", id))
+			if _, err := service.Delete(id, "", rtype, false); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %s: %s\n", id, err.Error())
+			}
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(diffCmd)
+	diffCmd.Flags().StringVar(&optType, "type", "image", "resource type: image, raw, video or pdf")
+	diffCmd.Flags().BoolVar(&optDeleteOrphans, "delete-orphans", false, "delete remote resources missing locally (keep files are never deleted)")
+}
+
+// localPublicIDs returns the public ids of the files in dir. Empty and
+// temporary files are ignored since they are never uploaded.
+func localPublicIDs(dir, prepend string) ([]string, error) {
+	ids := make([]string, 0)
+	err := filepath.Walk(dir, func(path string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if fi.IsDir() || fi.Size() == 0 || isTempFile(path) {
+			return nil
+		}
+		ids = append(ids, cloudinary.CleanExtensionNameWithPrepend(path, prepend))
+		return nil
+	})
+	return ids, err
+}
+
+// remotePublicIDs returns the public ids of the resources below the
+// prepend path.
+func remotePublicIDs(res []*cloudinary.Resource, prepend string) []string {
+	prefix := ""
+	if prepend = strings.Trim(prepend, "/"); prepend != "" {
+		prefix = prepend + "/"
+	}
+	ids := make([]string, 0, len(res))
+	for _, r := range res {
+		if strings.HasPrefix(r.PublicId, prefix) {
+			ids = append(ids, r.PublicId)
+		}
+	}
+	return ids
+}
+
+// diffSets splits public ids into the ones only found locally, the ones
+// only found remotely and the ones found on both sides. All three lists
+// are sorted and free of duplicates.
+func diffSets(local, remote []string) (toUpload, orphans, common []string) {
+	inLocal := make(map[string]bool, len(local))
+	for _, id := range local {
+		inLocal[id] = true
+	}
+	inRemote := make(map[string]bool, len(remote))
+	for _, id := range remote {
+		inRemote[id] = true
+	}
+	toUpload, orphans, common = []string{}, []string{}, []string{}
+	for id := range inLocal {
+		if inRemote[id] {
+			common = append(common, id)
+		} else {
+			toUpload = append(toUpload, id)
+		}
+	}
+	for id := range inRemote {
+		if !inLocal[id] {
+			orphans = append(orphans, id)
+		}
+	}
+	sort.Strings(toUpload)
+	sort.Strings(orphans)
+	sort.Strings(common)
+	return toUpload, orphans, common
+}
+
+func printSet(caption string, ids []string) {
+	step(fmt.Sprintf("%s %d", caption, len(ids)))
+	for _, id := range ids {
+		fmt.Fprintln(out, id)
+	}
+}
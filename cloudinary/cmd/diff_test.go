@@ -0,0 +1,77 @@
+// Copyright © 2017 Jimmy Song
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	cloudinary "github.com/rootsongjc/cloudinary-go"
+)
+
+func TestDiffSets(t *testing.T) {
+	local := []string{"img/b", "img/a", "img/c", "img/a"}
+	remote := []string{"img/c", "img/d", "img/b", "img/e"}
+	toUpload, orphans, common := diffSets(local, remote)
+	if exp := []string{"img/a"}; !reflect.DeepEqual(toUpload, exp) {
+		t.Errorf("wrong files to upload. Expect %v, got %v", exp, toUpload)
+	}
+	if exp := []string{"img/d", "img/e"}; !reflect.DeepEqual(orphans, exp) {
+		t.Errorf("wrong orphans. Expect %v, got %v", exp, orphans)
+	}
+	if exp := []string{"img/b", "img/c"}; !reflect.DeepEqual(common, exp) {
+		t.Errorf("wrong common files. Expect %v, got %v", exp, common)
+	}
+
+	toUpload, orphans, common = diffSets(nil, nil)
+	if len(toUpload)+len(orphans)+len(common) != 0 {
+		t.Error("expect empty sets")
+	}
+}
+
+func TestRemotePublicIDs(t *testing.T) {
+	res := []*cloudinary.Resource{{PublicId: "img/a"}, {PublicId: "imgs/b"}, {PublicId: "c"}}
+	if ids := remotePublicIDs(res, "/img/"); !reflect.DeepEqual(ids, []string{"img/a"}) {
+		t.Errorf("expect only resources below img/, got %v", ids)
+	}
+	if ids := remotePublicIDs(res, ""); len(ids) != 3 {
+		t.Errorf("expect all resources without prepend path, got %v", ids)
+	}
+}
+
+func TestLocalPublicIDs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "diff")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for name, data := range map[string]string{"a.png": "png", "sub/b.jpg": "jpg", "empty.png": "", "c.png~": "tmp"} {
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := ioutil.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ids, err := localPublicIDs(dir, "img")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp := []string{"img/a", "img/b"}; !reflect.DeepEqual(ids, exp) {
+		t.Errorf("wrong local public ids. Expect %v, got %v", exp, ids)
+	}
+}
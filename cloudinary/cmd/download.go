@@ -0,0 +1,60 @@
+// Copyright © 2017 Jimmy Song
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"os"
+
+	cloudinary "github.com/rootsongjc/cloudinary-go"
+	"github.com/spf13/cobra"
+)
+
+var optDownloadFile string
+var optDecompress bool
+
+// downloadCmd represents the download command
+var downloadCmd = &cobra.Command{
+	Use:   "download",
+	Short: "Download a file from its delivery URL",
+	Run: func(cmd *cobra.Command, args []string) {
+		if optRaw == "" && optImg == "" {
+			fail("Missing -i or -r option.")
+		}
+		rtype := cloudinary.ImageType
+		publicID := composePublicID(optImg)
+		if optRaw != "" {
+			rtype = cloudinary.RawType
+			publicID = composePublicID(optRaw)
+		}
+		w := out
+		if optDownloadFile != "" {
+			f, err := os.Create(optDownloadFile)
+			if err != nil {
+				perror(err)
+			}
+			defer f.Close()
+			w = f
+		}
+		if err := service.Download(w, publicID, rtype, optDecompress); err != nil {
+			perror(err)
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(downloadCmd)
+	downloadCmd.Flags().StringVar(&optDownloadFile, "file", "", "write the file here instead of the standard output")
+	downloadCmd.Flags().BoolVar(&optDecompress, "decompress", false, "gunzip files uploaded with put --compress")
+}
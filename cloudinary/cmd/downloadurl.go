@@ -0,0 +1,58 @@
+// Copyright © 2017 Jimmy Song
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	cloudinary "github.com/rootsongjc/cloudinary-go"
+	"github.com/spf13/cobra"
+)
+
+var optExpires time.Duration
+var optDownloadFormat string
+
+// downloadURLCmd represents the download-url command
+var downloadURLCmd = &cobra.Command{
+	Use:   "download-url",
+	Short: "Print a signed download URL of a file, valid for a limited time",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if optRaw == "" && optImg == "" {
+			fail("Missing -i or -r option.")
+		}
+		rtype := cloudinary.ImageType
+		publicID := composePublicID(optImg)
+		if optRaw != "" {
+			rtype = cloudinary.RawType
+			publicID = composePublicID(optRaw)
+		}
+		if optExpires <= 0 {
+			fail("--expires must be positive.")
+		}
+		u, err := service.PrivateDownloadURL(publicID, optDownloadFormat, rtype, time.Now().Add(optExpires))
+		if err != nil {
+			perror(err)
+		}
+		fmt.Fprintln(out, u)
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(downloadURLCmd)
+	downloadURLCmd.Flags().DurationVar(&optExpires, "expires", time.Hour, "validity of the URL, e.g. 15m or 24h")
+	downloadURLCmd.Flags().StringVar(&optDownloadFormat, "format", "", "format of the downloaded file, e.g. pdf")
+}
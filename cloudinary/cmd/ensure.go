@@ -0,0 +1,71 @@
+// Copyright © 2017 Jimmy Song
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	cloudinary "github.com/rootsongjc/cloudinary-go"
+	"github.com/spf13/cobra"
+)
+
+// ensureCmd represents the ensure command
+var ensureCmd = &cobra.Command{
+	Use:   "ensure",
+	Short: "Upload a file if missing and tag it if untagged",
+	Run: func(cmd *cobra.Command, args []string) {
+		if optEnsureFile == "" {
+			fail("Missing --file option.")
+		}
+		if optRaw == "" && optImg == "" {
+			fail("Missing -i or -r option.")
+		}
+		rtype := cloudinary.ImageType
+		publicID := composePublicID(optImg)
+		if optRaw != "" {
+			rtype = cloudinary.RawType
+			publicID = composePublicID(optRaw)
+		}
+		printPublicID(publicID)
+		connectDatabase(false)
+		res, err := service.Ensure(optEnsureFile, publicID, optEnsureTag, rtype)
+		if err != nil {
+			perror(err)
+		}
+		printEnsureResult(publicID, optEnsureTag, res)
+	},
+}
+
+var optEnsureFile string
+var optEnsureTag string
+
+func init() {
+	RootCmd.AddCommand(ensureCmd)
+	ensureCmd.Flags().StringVar(&optEnsureFile, "file", "", "local file uploaded if the resource is missing")
+	ensureCmd.Flags().StringVar(&optEnsureTag, "tag", "", "tag the resource must have")
+}
+
+func printEnsureResult(publicID, tag string, res *cloudinary.EnsureResult) {
+	switch {
+	case res.Uploaded && res.Tagged:
+		step(fmt.Sprintf("Uploaded %s with tag %s", publicID, tag))
+	case res.Uploaded:
+		step(fmt.Sprintf("Uploaded %s", publicID))
+	case res.Tagged:
+		step(fmt.Sprintf("Tagged %s with %s", publicID, tag))
+	default:
+		step("No change")
+	}
+}
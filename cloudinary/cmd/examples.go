@@ -0,0 +1,137 @@
+// Copyright © 2017 Jimmy Song
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// example is a command line shown by the examples command. Args don't
+// include the program name.
+type example struct {
+	Description string
+	Args        []string
+}
+
+func (e example) commandLine() string {
+	words := make([]string, len(e.Args))
+	for i, a := range e.Args {
+		if strings.ContainsAny(a, " \"'|") {
+			a = fmt.Sprintf("%q", a)
+		}
+		words[i] = a
+	}
+	return "cloudinary " + strings.Join(words, " ")
+}
+
+var examples = []example{
+	{"Upload an image below the images/ prefix", []string{"put", "-i", "abc.jpg", "-p", "images"}},
+	{"Upload a raw file, gzipped", []string{"put", "-r", "data/catalog.json", "--compress"}},
+	{"Upload a directory, mirroring its structure as folders", []string{"put", "-i", "static", "--preserve-structure"}},
+	{"Upload an image and print its dominant colors", []string{"put", "-i", "cover.jpg", "--colors"}},
+	{"Delete an image and purge its cached copies from the CDN", []string{"rm", "-i", "abc", "-p", "images", "--invalidate"}},
+	{"Delete a raw file, printing the result as JSON", []string{"rm", "-r", "abc.js", "--output", "json"}},
+	{"Signed URL of an image, with a transformation", []string{"url", "-i", "cover.jpg", "--transformation", "w_300,h_200,c_fill", "--sign"}},
+	{"Responsive URL, width and pixel ratio set by the browser", []string{"url", "-i", "cover.jpg", "--crop", "scale", "--width", "auto", "--dpr", "auto"}},
+	{"Remote image delivered through the fetch proxy", []string{"fetch-url", "--url", "https://example.com/x.jpg", "-t", "w_200"}},
+	{"Check a transformation for typos", []string{"validate-transformation", "w_300,c_fill,g_face"}},
+	{"Add a tag and a context entry", []string{"update", "-i", "logo.png", "--add-tag", "brand", "--set-context", "alt=Logo"}},
+	{"Remove a context entry from several images", []string{"context", "remove", "--key", "campaign", "-i", "banners/summer", "-i", "banners/winter"}},
+	{"Approve an image pending manual moderation", []string{"moderate", "approve", "-i", "uploads/avatar"}},
+}
+
+var optRunExample int
+
+// examplesCmd represents the examples command
+var examplesCmd = &cobra.Command{
+	Use:   "examples [command]",
+	Short: "Print example command lines, and run them in simulation mode",
+	Long: `Print example command lines, all of them or those of a command.
+
+With --run, the example of the given number is run in simulation mode,
+without any request to the Cloudinary service.`,
+	Args: cobra.MaximumNArgs(1),
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		// Listing examples doesn't need a configured service
+		if optRunExample > 0 {
+			initConfig()
+		}
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		var name string
+		if len(args) > 0 {
+			name = args[0]
+		}
+		selected := examplesOf(name)
+		if len(selected) == 0 {
+			fail(fmt.Sprintf("No example of command %q.", name))
+		}
+		if optRunExample == 0 {
+			printExamples(out, selected)
+			return
+		}
+		if optRunExample > len(selected) {
+			fail(fmt.Sprintf("No example %d, there are %d.", optRunExample, len(selected)))
+		}
+		if err := runExample(selected[optRunExample-1]); err != nil {
+			perror(err)
+		}
+	},
+}
+
+// examplesOf returns the examples of the name command, or all of them
+// if name is empty.
+func examplesOf(name string) []example {
+	selected := make([]example, 0, len(examples))
+	for _, e := range examples {
+		if name == "" || e.Args[0] == name {
+			selected = append(selected, e)
+		}
+	}
+	return selected
+}
+
+func printExamples(w io.Writer, selected []example) {
+	for i, e := range selected {
+		fmt.Fprintf(w, "%2d. %s\n    %s\n", i+1, e.Description, e.commandLine())
+	}
+}
+
+// runExample runs the example command in simulation mode. The service
+// is set offline too, so that no request can be sent.
+func runExample(e example) error {
+	c, args, err := RootCmd.Find(e.Args)
+	if err != nil {
+		return err
+	}
+	if err := c.ParseFlags(args); err != nil {
+		return err
+	}
+	optSimulate, optOffline = true, true
+	service.Simulate(true)
+	service.SetOffline()
+	step("Simulating: " + e.commandLine())
+	c.Run(c, c.Flags().Args())
+	return nil
+}
+
+func init() {
+	RootCmd.AddCommand(examplesCmd)
+	examplesCmd.Flags().IntVar(&optRunExample, "run", 0, "run the example of this number in simulation mode")
+}
@@ -0,0 +1,94 @@
+// Copyright © 2017 Jimmy Song
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	cloudinary "github.com/rootsongjc/cloudinary-go"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// resetFlags sets the flags of c, and the variables they are bound to,
+// back to their default values.
+func resetFlags(c *cobra.Command) {
+	c.Flags().VisitAll(func(f *pflag.Flag) {
+		if sv, ok := f.Value.(pflag.SliceValue); ok {
+			sv.Replace(nil)
+		} else {
+			f.Value.Set(f.DefValue)
+		}
+		f.Changed = false
+	})
+}
+
+func TestExamples(t *testing.T) {
+	if len(examples) == 0 {
+		t.Fatal("no example")
+	}
+	for _, e := range examples {
+		c, args, err := RootCmd.Find(e.Args)
+		if err != nil || c.Run == nil {
+			t.Errorf("%s: not a command", e.commandLine())
+			continue
+		}
+		if err := c.ParseFlags(args); err != nil {
+			t.Errorf("%s: %s", e.commandLine(), err)
+		}
+		resetFlags(c)
+	}
+	if n := len(examplesOf("rm")); n == 0 || n == len(examples) {
+		t.Errorf("expect the rm examples only, got %d", n)
+	}
+	if len(examplesOf("nope")) != 0 {
+		t.Error("expect no example of an unknown command")
+	}
+}
+
+func TestRunExampleOffline(t *testing.T) {
+	defer func(s *cloudinary.Service) {
+		service = s
+		optSimulate, optOffline, optQuiet = false, false, false
+	}(service)
+
+	for _, e := range examples {
+		if e.Args[0] == "put" {
+			// The files to upload don't exist
+			continue
+		}
+		var err error
+		if service, err = cloudinary.NewService("cloudname", "key", "secret"); err != nil {
+			t.Fatal(err)
+		}
+		stdout := captureStdout(t, func() {
+			captureOutput(func() {
+				if err := runExample(e); err != nil {
+					t.Errorf("%s: %s", e.commandLine(), err)
+				}
+			})
+		})
+		c, _, _ := RootCmd.Find(e.Args)
+		resetFlags(c)
+		if n := service.Metrics().Requests; n != 0 {
+			t.Errorf("%s: expect no request in simulation mode, got %d", e.commandLine(), n)
+		}
+		if !optQuiet && !strings.Contains(stdout, "Simulating: "+e.commandLine()) {
+			t.Errorf("%s: expect the command line to be printed, got %q", e.commandLine(), stdout)
+		}
+		optQuiet = false
+	}
+}
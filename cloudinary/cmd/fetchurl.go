@@ -0,0 +1,46 @@
+// Copyright © 2017 Jimmy Song
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var optRemoteURL, optFetchTransformation string
+
+// fetchURLCmd represents the fetch-url command
+var fetchURLCmd = &cobra.Command{
+	Use:   "fetch-url",
+	Short: "Print the signed URL delivering a remote image through Cloudinary",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if optRemoteURL == "" {
+			fail("Missing --url option.")
+		}
+		u, err := service.FetchURL(optRemoteURL, optFetchTransformation)
+		if err != nil {
+			perror(err)
+		}
+		fmt.Fprintln(out, u)
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(fetchURLCmd)
+	fetchURLCmd.Flags().StringVar(&optRemoteURL, "url", "", "URL of the remote image, e.g. https://example.com/x.jpg")
+	fetchURLCmd.Flags().StringVarP(&optFetchTransformation, "transformation", "t", "", "transformation, e.g. w_200")
+}
@@ -0,0 +1,79 @@
+// Copyright © 2017 Jimmy Song
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// folderCmd represents the folder command
+var folderCmd = &cobra.Command{
+	Use:   "folder",
+	Short: "Manage folders",
+}
+
+var folderLsCmd = &cobra.Command{
+	Use:   "ls [parent]",
+	Short: "List sub-folders (root folders by default)",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		var parent string
+		if len(args) > 0 {
+			parent = args[0]
+		}
+		folders, err := service.Folders(parent)
+		if err != nil {
+			perror(err)
+		}
+		if len(folders) == 0 {
+			info("No folder found.")
+			return
+		}
+		for _, f := range folders {
+			fmt.Fprintln(out, f)
+		}
+	},
+}
+
+var folderCreateCmd = &cobra.Command{
+	Use:   "create <path>",
+	Short: "Create a folder",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		step(fmt.Sprintf("Creating folder %s", args[0]))
+		if err := service.CreateFolder(args[0]); err != nil {
+			perror(err)
+		}
+	},
+}
+
+var folderRmCmd = &cobra.Command{
+	Use:   "rm <path>",
+	Short: "Remove an empty folder",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		step(fmt.Sprintf("Removing folder %s", args[0]))
+		if err := service.DeleteFolder(args[0]); err != nil {
+			perror(err)
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(folderCmd)
+	folderCmd.AddCommand(folderLsCmd, folderCreateCmd, folderRmCmd)
+}
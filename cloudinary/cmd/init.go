@@ -0,0 +1,159 @@
+// Copyright © 2017 Jimmy Song
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	cloudinary "github.com/rootsongjc/cloudinary-go"
+	"github.com/spf13/cobra"
+)
+
+// initOptions holds the values written to a new config file.
+type initOptions struct {
+	CloudName string
+	APIKey    string
+	APISecret string
+	Prepend   string
+	MongoURI  string
+}
+
+var optInit initOptions
+var optForce bool
+
+// initCmd represents the init command
+var initCmd = &cobra.Command{
+	Use:   "init",
+	Short: "Create a config file",
+	// No config file to load yet
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {},
+	Run: func(cmd *cobra.Command, args []string) {
+		path := cfgFile
+		if path == "" {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				perror(err)
+			}
+			path = filepath.Join(home, ".cloudinary.toml")
+		}
+		if _, err := os.Stat(path); err == nil && !optForce {
+			fail(fmt.Sprintf("%s already exists (use --force to overwrite)", path))
+		}
+		in := bufio.NewReader(os.Stdin)
+		prompts := []struct {
+			flag, caption string
+			value         *string
+		}{
+			{"cloud-name", "Cloud name", &optInit.CloudName},
+			{"api-key", "API key", &optInit.APIKey},
+			{"api-secret", "API secret", &optInit.APISecret},
+			{"prepend", "Default prepend path (optional)", &optInit.Prepend},
+			{"mongo-uri", "MongoDB URI (optional)", &optInit.MongoURI},
+		}
+		for _, p := range prompts {
+			if cmd.Flags().Changed(p.flag) {
+				continue
+			}
+			v, err := prompt(in, p.caption)
+			if err != nil {
+				perror(err)
+			}
+			*p.value = v
+		}
+		if err := optInit.validate(); err != nil {
+			perror(err)
+		}
+
+		step("Checking credentials")
+		s, err := cloudinary.Dial(optInit.uri())
+		if err != nil {
+			perror(err)
+		}
+		if err := s.Ping(); err != nil {
+			perror(err)
+		}
+		if err := writeConfig(path, &optInit, optForce); err != nil {
+			perror(err)
+		}
+		step(fmt.Sprintf("Config written to %s", path))
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(initCmd)
+	initCmd.Flags().StringVar(&optInit.CloudName, "cloud-name", "", "cloud name")
+	initCmd.Flags().StringVar(&optInit.APIKey, "api-key", "", "API key")
+	initCmd.Flags().StringVar(&optInit.APISecret, "api-secret", "", "API secret")
+	initCmd.Flags().StringVar(&optInit.Prepend, "prepend", "", "default remote prepend path")
+	initCmd.Flags().StringVar(&optInit.MongoURI, "mongo-uri", "", "MongoDB URI used for upload sync")
+	initCmd.Flags().BoolVar(&optForce, "force", false, "overwrite an existing config file")
+}
+
+func prompt(in *bufio.Reader, caption string) (string, error) {
+	fmt.Printf("%s: ", caption)
+	line, err := in.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
+
+func (o *initOptions) validate() error {
+	if o.CloudName == "" || o.APIKey == "" || o.APISecret == "" {
+		return errors.New("cloud name, API key and API secret are required")
+	}
+	if o.MongoURI != "" && !strings.HasPrefix(o.MongoURI, "mongodb://") {
+		return errors.New("Missing mongodb:// scheme in MongoDB URI")
+	}
+	return nil
+}
+
+// uri returns the cloudinary:// URI built from the options.
+func (o *initOptions) uri() string {
+	u := &url.URL{
+		Scheme: "cloudinary",
+		User:   url.UserPassword(o.APIKey, o.APISecret),
+		Host:   o.CloudName,
+	}
+	return u.String()
+}
+
+// writeConfig writes a config file readable by LoadConfig. An existing
+// file is only overwritten if force is true.
+func writeConfig(path string, o *initOptions, force bool) error {
+	if _, err := os.Stat(path); err == nil && !force {
+		return fmt.Errorf("%s already exists", path)
+	}
+	var b strings.Builder
+	b.WriteString("[cloudinary]\n")
+	fmt.Fprintf(&b, "uri = %s\n", strconv.Quote(o.uri()))
+	if o.Prepend != "" {
+		fmt.Fprintf(&b, "prepend = %s\n", strconv.Quote(o.Prepend))
+	}
+	if o.MongoURI != "" {
+		b.WriteString("\n[database]\n")
+		fmt.Fprintf(&b, "uri = %s\n", strconv.Quote(o.MongoURI))
+	}
+	return ioutil.WriteFile(path, []byte(b.String()), 0600)
+}
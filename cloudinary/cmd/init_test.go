@@ -0,0 +1,73 @@
+// Copyright © 2017 Jimmy Song
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestWriteConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cloudinary")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, ".cloudinary.toml")
+	o := &initOptions{
+		CloudName: "cloud",
+		APIKey:    "key",
+		APISecret: "secret",
+		Prepend:   "images",
+		MongoURI:  "mongodb://localhost/cloudinary",
+	}
+	if err := writeConfig(path, o, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeConfig(path, o, false); err == nil {
+		t.Error("should refuse to overwrite an existing config file")
+	}
+	if err := writeConfig(path, o, true); err != nil {
+		t.Errorf("should overwrite with force: %s", err)
+	}
+
+	viper.Reset()
+	defer func(c *Config) {
+		viper.Reset()
+		settings = c
+	}(settings)
+	settings = &Config{}
+	viper.SetConfigFile(path)
+	if err := viper.ReadInConfig(); err != nil {
+		t.Fatal(err)
+	}
+	c, err := LoadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.CloudinaryURI.String() != o.uri() {
+		t.Errorf("wrong cloudinary URI. Expect %s, got %s", o.uri(), c.CloudinaryURI)
+	}
+	if c.PrependPath != "images/" {
+		t.Errorf("wrong prepend path. Expect images/, got %s", c.PrependPath)
+	}
+	if c.MongoURI == nil || c.MongoURI.String() != o.MongoURI {
+		t.Errorf("wrong mongoDB URI. Expect %s, got %v", o.MongoURI, c.MongoURI)
+	}
+}
@@ -0,0 +1,183 @@
+// Copyright © 2017 Jimmy Song
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	cloudinary "github.com/rootsongjc/cloudinary-go"
+	"github.com/spf13/cobra"
+)
+
+// Statuses of asynchronous jobs, see reconcileJobs.
+const (
+	jobDone    = "done"
+	jobPending = "pending"
+	jobFailed  = "failed" // An add-on failed to process the file
+	jobError   = "error"  // The status couldn't be checked
+)
+
+// asyncJob is an asynchronous upload, or add-on processing, not seen
+// complete yet.
+type asyncJob struct {
+	Token    string    `json:"token"`
+	Path     string    `json:"path"` // Local file uploaded
+	QueuedAt time.Time `json:"queued_at"`
+}
+
+// jobRegistry collects the jobs queued by an upload. It is safe for
+// concurrent use.
+type jobRegistry struct {
+	mu   sync.Mutex
+	jobs []*asyncJob
+}
+
+func (r *jobRegistry) add(path, token string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.jobs = append(r.jobs, &asyncJob{Token: token, Path: path, QueuedAt: time.Now().UTC()})
+}
+
+// saveJobs adds the jobs of r, but the one identified by skip if not
+// empty, to those of the state file.
+func saveJobs(r *jobRegistry, skip string) error {
+	path, err := stateFile()
+	if err != nil {
+		return err
+	}
+	return r.save(path, skip)
+}
+
+// save adds the jobs, but the one identified by skip if not empty, to
+// those of the state file at path. A job queued again replaces the
+// previous one.
+func (r *jobRegistry) save(path, skip string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	st, err := readState(path)
+	if err != nil {
+		return err
+	}
+	for _, j := range r.jobs {
+		if j.Token == skip {
+			continue
+		}
+		kept := st.Jobs[:0]
+		for _, old := range st.Jobs {
+			if old.Token != j.Token {
+				kept = append(kept, old)
+			}
+		}
+		st.Jobs = append(kept, j)
+	}
+	return writeState(path, st)
+}
+
+// jobResult is the status of a job, and its error if it couldn't be
+// checked.
+type jobResult struct {
+	job    *asyncJob
+	status string
+	err    error
+}
+
+// reconcileJobs checks the status of each job and returns them along
+// with the jobs still to follow up: pending ones, and those whose
+// status couldn't be checked.
+func reconcileJobs(jobs []*asyncJob, check func(token string) (bool, *cloudinary.Resource, error)) ([]jobResult, []*asyncJob) {
+	results := make([]jobResult, 0, len(jobs))
+	remaining := make([]*asyncJob, 0)
+	for _, j := range jobs {
+		done, res, err := check(j.Token)
+		r := jobResult{job: j, status: jobPending, err: err}
+		switch {
+		case err != nil:
+			r.status = jobError
+		case done && addonFailed(res):
+			r.status = jobFailed
+		case done:
+			r.status = jobDone
+		}
+		if r.status == jobPending || r.status == jobError {
+			remaining = append(remaining, j)
+		}
+		results = append(results, r)
+	}
+	return results, remaining
+}
+
+// addonFailed reports whether an add-on failed to process res.
+func addonFailed(res *cloudinary.Resource) bool {
+	if res == nil || res.Info == nil {
+		return false
+	}
+	for _, st := range res.Info.BackgroundRemoval {
+		if st != nil && st.Status == "failed" {
+			return true
+		}
+	}
+	return false
+}
+
+// jobsCmd represents the jobs command
+var jobsCmd = &cobra.Command{
+	Use:   "jobs",
+	Short: "Follow up asynchronous uploads",
+}
+
+var jobsLsCmd = &cobra.Command{
+	Use:   "ls",
+	Short: "Check the status of the asynchronous uploads queued with put",
+	Long: `Check the status of the asynchronous uploads queued with put, and of
+the add-ons processing files, e.g. with --remove-bg.
+
+Completed jobs, done or failed, are then forgotten.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		path, err := stateFile()
+		if err != nil {
+			perror(err)
+		}
+		st, err := readState(path)
+		if err != nil {
+			perror(err)
+		}
+		if len(st.Jobs) == 0 {
+			info("No pending job.")
+			return
+		}
+		results, remaining := reconcileJobs(st.Jobs, service.UploadStatus)
+		for _, r := range results {
+			fmt.Fprintf(out, "%-8s %-40s %s\n", r.status, r.job.Token, r.job.Path)
+			if r.err != nil {
+				fmt.Fprintf(out, "         %s\n", r.err.Error())
+			}
+		}
+		if optSimulate {
+			return
+		}
+		st.Jobs = remaining
+		if err := writeState(path, st); err != nil {
+			perror(err)
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(jobsCmd)
+	jobsCmd.AddCommand(jobsLsCmd)
+}
@@ -0,0 +1,110 @@
+// Copyright © 2017 Jimmy Song
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	cloudinary "github.com/rootsongjc/cloudinary-go"
+)
+
+func TestJobRegistrySave(t *testing.T) {
+	dir, err := ioutil.TempDir("", "state")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "state.json")
+
+	r := new(jobRegistry)
+	r.add("a.png", "image:a")
+	r.add("b.png", "image:b")
+	r.add("c.png", "image:c")
+	if err := r.save(path, "image:c"); err != nil {
+		t.Fatal(err)
+	}
+	// The state of ls --since-last is kept, a job queued again replaced
+	st, err := readState(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	st.LastCreatedAt = st.LastCreatedAt.AddDate(1, 0, 0)
+	if err := writeState(path, st); err != nil {
+		t.Fatal(err)
+	}
+	r = new(jobRegistry)
+	r.add("a2.png", "image:a")
+	r.add("d.txt", "raw:d.txt")
+	if err := r.save(path, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := readState(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.LastCreatedAt.Equal(st.LastCreatedAt) {
+		t.Error("the ls state should be kept")
+	}
+	tokens := make([]string, len(got.Jobs))
+	for i, j := range got.Jobs {
+		tokens[i] = j.Token + " " + j.Path
+	}
+	exp := []string{"image:b b.png", "image:a a2.png", "raw:d.txt d.txt"}
+	if len(tokens) != len(exp) {
+		t.Fatalf("expect jobs %v, got %v", exp, tokens)
+	}
+	for i := range exp {
+		if tokens[i] != exp[i] || got.Jobs[i].QueuedAt.IsZero() {
+			t.Errorf("expect jobs %v, got %v", exp, tokens)
+			break
+		}
+	}
+}
+
+func TestReconcileJobs(t *testing.T) {
+	jobs := []*asyncJob{{Token: "image:done"}, {Token: "image:pending"}, {Token: "image:failed"}, {Token: "image:unreachable"}}
+	check := func(token string) (bool, *cloudinary.Resource, error) {
+		switch token {
+		case "image:done":
+			return true, &cloudinary.Resource{PublicId: "done"}, nil
+		case "image:failed":
+			info := &cloudinary.Info{BackgroundRemoval: map[string]*cloudinary.AddonStatus{"cloudinary_ai": {Status: "failed"}}}
+			return true, &cloudinary.Resource{PublicId: "failed", Info: info}, nil
+		case "image:unreachable":
+			return false, nil, errors.New("connection refused")
+		}
+		return false, nil, nil
+	}
+
+	results, remaining := reconcileJobs(jobs, check)
+	exp := []string{jobDone, jobPending, jobFailed, jobError}
+	for i, r := range results {
+		if r.job != jobs[i] || r.status != exp[i] {
+			t.Errorf("%s: expect %s, got %s", jobs[i].Token, exp[i], r.status)
+		}
+	}
+	if results[3].err == nil {
+		t.Error("the check error should be reported")
+	}
+	// Completed jobs are pruned
+	if len(remaining) != 2 || remaining[0] != jobs[1] || remaining[1] != jobs[3] {
+		t.Errorf("expect the pending and unchecked jobs to remain, got %v", remaining)
+	}
+}
@@ -0,0 +1,46 @@
+// Copyright © 2017 Jimmy Song
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// limitsCmd represents the limits command
+var limitsCmd = &cobra.Command{
+	Use:   "limits",
+	Short: "Print the admin API rate limit status",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		rl, err := service.RateLimitStatus()
+		if err != nil {
+			perror(err)
+		}
+		fmt.Fprintf(out, "%-10s %d\n", "Limit:", rl.Limit)
+		fmt.Fprintf(out, "%-10s %d\n", "Remaining:", rl.Remaining)
+		reset := rl.Reset.Local().Format("2006-01-02 15:04:05 MST")
+		if d := time.Until(rl.Reset); d > 0 {
+			reset += fmt.Sprintf(" (in %s)", d.Round(time.Second))
+		}
+		fmt.Fprintf(out, "%-10s %s\n", "Reset:", reset)
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(limitsCmd)
+}
@@ -17,7 +17,10 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	cloudinary "github.com/rootsongjc/cloudinary-go"
 	"github.com/spf13/cobra"
@@ -28,44 +31,420 @@ var lsCmd = &cobra.Command{
 	Use:   "ls",
 	Short: "List files",
 	Run: func(cmd *cobra.Command, args []string) {
+		if err := checkOutputFormat(optOutput); err != nil {
+			fail(err.Error())
+		}
+		if err := checkFields(optFields); err != nil {
+			fail(err.Error())
+		}
+		showListProgress()
+		if optSinceLast {
+			if len(optIds) > 0 || optImg != "" || optRaw != "" {
+				fail("--since-last can't be used with --ids, -i or -r.")
+			}
+			listSinceLast()
+			return
+		}
+		if optProtected {
+			listProtected()
+			return
+		}
+		if len(optIds) > 0 {
+			rtype := cloudinary.ImageType
+			if optRaw != "" {
+				rtype = cloudinary.RawType
+			}
+			res, err := service.ResourcesByIDs(optIds, rtype)
+			printResources(res, err)
+			if missing := missingIDs(optIds, res); len(missing) > 0 {
+				info("Not found:", strings.Join(missing, ", "))
+			}
+			return
+		}
 		// list all resources
 		if optImg == "" && optRaw == "" {
-			fmt.Println("==> Raw resources:")
-			printResources(service.Resources(cloudinary.RawType))
-			fmt.Println("==> Images:")
-			printResources(service.Resources(cloudinary.ImageType))
-		} else { // list image resources
-			var publicID string
-			if optImg != "" {
-				publicID = composePublicID(optImg)
-				printPublicID(publicID)
-				fmt.Println("==> Image Details:")
+			if optMaxResults != 0 || optCursor != "" {
+				listPage(cmd)
+				return
+			}
+			if optParallelList {
+				listParallel()
+				return
 			}
+			if optOutput != outputTable {
+				// A single document for both resource types
+				raws, err := fetchResources(cloudinary.RawType)
+				if err != nil {
+					fail(err.Error())
+				}
+				imgs, err := fetchResources(cloudinary.ImageType)
+				printResources(append(raws, imgs...), err)
+				return
+			}
+			step("Raw resources:")
+			printResources(fetchResources(cloudinary.RawType))
+			step("Images:")
+			printResources(fetchResources(cloudinary.ImageType))
+		} else { // list image resources
 			if optRaw != "" {
-				fmt.Println("List raw resource details Not support")
-				os.Exit(0)
+				publicID := composePublicID(optRaw)
+				printPublicID(publicID)
+				step("Raw Details:")
+				printResourceDetails(service.RawResourceDetails(publicID))
+				return
 			}
+			publicID := composePublicID(optImg)
+			printPublicID(publicID)
+			step("Image Details:")
 			printResourceDetails(service.ResourceDetails(publicID))
 		}
 	},
 }
 
+var optShowTags bool
+var optIds []string
+var optTag string
+var optWithoutTag string
+var optOutput string
+var optSinceLast bool
+var optParallelList bool
+var optFormat string
+var optProtected bool
+var optMaxResults int
+var optCursor string
+var optFields []string
+
 func init() {
 	RootCmd.AddCommand(lsCmd)
+	lsCmd.Flags().BoolVar(&optShowTags, "show-tags", false, "show resource tags")
+	lsCmd.Flags().StringVar(&optTag, "tag", "", "only list resources tagged with this tag")
+	lsCmd.Flags().StringVar(&optWithoutTag, "without-tag", "", "only list resources not tagged with this tag")
+	lsCmd.Flags().StringVar(&optFormat, "format", "", "only list resources of this format, e.g. svg or pdf")
+	lsCmd.Flags().StringVarP(&optOutput, "output", "o", outputTable, "output format: table, json, csv or template=<Go template>, e.g. 'template={{.PublicId}} {{humanBytes .Size}}'")
+	lsCmd.Flags().BoolVar(&optSinceLast, "since-last", false, "only list resources uploaded since the last --since-last run")
+	lsCmd.Flags().BoolVar(&optParallelList, "parallel-list", false, "fetch the raw and image inventories concurrently")
+	lsCmd.Flags().BoolVar(&optProtected, "protected", false, "only list resources protected from deletion by the keepfiles pattern")
+	lsCmd.Flags().IntVar(&optMaxResults, "max-results", 0, "list at most this number of resources of each type, and print the cursor of the next ones")
+	lsCmd.Flags().StringVar(&optCursor, "cursor", "", "list the resources starting at this cursor, printed by a previous --max-results run (requires --type)")
+	lsCmd.Flags().StringVar(&optType, "type", "image", "with --max-results or --cursor, only list this resource type: image, raw or video")
+	lsCmd.Flags().StringSliceVar(&optFields, "fields", nil, "only list these fields, e.g. public_id,bytes,format: "+strings.Join(cloudinary.ResourceFields, ", "))
+	lsCmd.Flags().StringSliceVar(&optIds, "ids", nil, "comma separated list of public ids to list (images, or raw files with -r)")
+}
+
+// fetchResources returns the rtype resources matching the --tag,
+// --without-tag and --format filters.
+func fetchResources(rtype cloudinary.ResourceType) ([]*cloudinary.Resource, error) {
+	res, err := fetchTagged(rtype)
+	if err != nil || optFormat == "" {
+		return res, err
+	}
+	return withFormat(res, optFormat), nil
+}
+
+// withFormat returns the resources of the given format.
+func withFormat(res []*cloudinary.Resource, format string) []*cloudinary.Resource {
+	format = cloudinary.NormalizeFormat(format)
+	filtered := make([]*cloudinary.Resource, 0, len(res))
+	for _, r := range res {
+		if r.Format == format {
+			filtered = append(filtered, r)
+		}
+	}
+	return filtered
+}
+
+// fetchTagged returns the rtype resources matching the --tag and
+// --without-tag filters.
+func fetchTagged(rtype cloudinary.ResourceType) ([]*cloudinary.Resource, error) {
+	if optTag == "" {
+		if optWithoutTag != "" {
+			return service.ResourcesWithoutTag(optWithoutTag, rtype)
+		}
+		if len(optFields) > 0 && optFormat == "" {
+			// Other listings are filtered client-side, see printResources
+			return service.ResourcesFields(rtype, optFields)
+		}
+		return service.Resources(rtype)
+	}
+	res, err := service.ResourcesByTag(optTag, rtype)
+	if err != nil || optWithoutTag == "" {
+		return res, err
+	}
+	filtered := make([]*cloudinary.Resource, 0, len(res))
+	for _, r := range res {
+		if !r.HasTag(optWithoutTag) {
+			filtered = append(filtered, r)
+		}
+	}
+	return filtered, nil
+}
+
+// inventory holds the resources of a given type, or the error met while
+// fetching them.
+type inventory struct {
+	caption string
+	res     []*cloudinary.Resource
+	err     error
+}
+
+// fetchInventories fetches the raw and image inventories, each one in
+// its own goroutine. Inventories are returned in that order, whatever
+// the order in which the fetches complete.
+func fetchInventories(fetch func(cloudinary.ResourceType) ([]*cloudinary.Resource, error)) []*inventory {
+	invs := []*inventory{{caption: "Raw resources:"}, {caption: "Images:"}}
+	rtypes := []cloudinary.ResourceType{cloudinary.RawType, cloudinary.ImageType}
+	var wg sync.WaitGroup
+	for i, rtype := range rtypes {
+		wg.Add(1)
+		go func(inv *inventory, rtype cloudinary.ResourceType) {
+			defer wg.Done()
+			inv.res, inv.err = fetch(rtype)
+		}(invs[i], rtype)
+	}
+	wg.Wait()
+	return invs
+}
+
+// listParallel lists all resources, fetching both inventories
+// concurrently. An error fetching one of them is reported but does not
+// prevent the other one from being printed.
+func listParallel() {
+	failed := false
+	all := make([]*cloudinary.Resource, 0)
+	for _, inv := range fetchInventories(fetchResources) {
+		if optOutput == outputTable {
+			step(inv.caption)
+		}
+		if inv.err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %s\n", inv.err.Error())
+			failed = true
+			continue
+		}
+		if optOutput == outputTable {
+			printResources(inv.res, nil)
+		} else {
+			all = append(all, inv.res...)
+		}
+	}
+	if optOutput != outputTable {
+		// A single document for both resource types
+		printResources(all, nil)
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// listPage lists the resources page by page, see --max-results and
+// --cursor. Both resource types are listed unless --type is set.
+func listPage(cmd *cobra.Command) {
+	if optMaxResults < 0 {
+		fail("--max-results must be positive.")
+	}
+	if optTag != "" || optWithoutTag != "" || optFormat != "" {
+		fail("--max-results and --cursor can't be used with --tag, --without-tag or --format.")
+	}
+	types := []cloudinary.ResourceType{cloudinary.RawType, cloudinary.ImageType}
+	if cmd.Flags().Changed("type") {
+		rtype, err := parseResourceType(optType)
+		if err != nil {
+			fail(err.Error())
+		}
+		types = []cloudinary.ResourceType{rtype}
+	} else if optCursor != "" {
+		fail("--cursor requires --type, cursors are specific to a resource type.")
+	}
+	all := make([]*cloudinary.Resource, 0)
+	cursors := make([]string, 0, len(types))
+	for _, rtype := range types {
+		res, next, err := service.ResourcesPage(rtype, optCursor, optMaxResults)
+		if err != nil {
+			fail(err.Error())
+		}
+		if optOutput == outputTable {
+			step(typeCaption(rtype))
+			printResources(res, nil)
+			if next != "" {
+				step("Next cursor: " + next)
+			}
+			continue
+		}
+		all = append(all, res...)
+		if next != "" {
+			cursors = append(cursors, fmt.Sprintf("%s next cursor: %s", strings.TrimSuffix(typeCaption(rtype), ":"), next))
+		}
+	}
+	if optOutput != outputTable {
+		// A single document, cursors are kept out of it
+		printResources(all, nil)
+		for _, c := range cursors {
+			fmt.Fprintln(os.Stderr, c)
+		}
+	}
+}
+
+// typeCaption returns the caption of the rtype resources listing.
+func typeCaption(rtype cloudinary.ResourceType) string {
+	switch rtype {
+	case cloudinary.RawType:
+		return "Raw resources:"
+	case cloudinary.VideoType:
+		return "Videos:"
+	}
+	return "Images:"
+}
+
+// listSinceLast lists the resources uploaded since the previous run,
+// then records the most recent creation date in the state file.
+func listSinceLast() {
+	path, err := stateFile()
+	if err != nil {
+		fail(err.Error())
+	}
+	st, err := readState(path)
+	if err != nil {
+		fail(err.Error())
+	}
+	if st.LastCreatedAt.IsZero() {
+		info("No previous run, listing all resources")
+	} else {
+		info("Resources uploaded since", st.LastCreatedAt.Format(time.RFC3339))
+	}
+	res, err := service.Search(sinceExpression(st.LastCreatedAt, optTag, optWithoutTag, optFormat))
+	printResources(res, err)
+	if optSimulate {
+		return
+	}
+	st.LastCreatedAt = latestCreatedAt(res, st.LastCreatedAt)
+	if err := writeState(path, st); err != nil {
+		fail(err.Error())
+	}
+}
+
+// listProtected lists the raw files and images matching the keepfiles
+// pattern, i.e. the ones rm refuses to delete.
+func listProtected() {
+	if settings.KeepFilesPattern == "" {
+		info("No keepfiles pattern set, no resource is protected.")
+		return
+	}
+	info("Protected by", settings.KeepFilesPattern)
+	raws, err := fetchResources(cloudinary.RawType)
+	if err != nil {
+		fail(err.Error())
+	}
+	imgs, err := fetchResources(cloudinary.ImageType)
+	printResources(protectedResources(append(raws, imgs...), service.Protected), err)
+}
+
+// protectedResources returns the resources for which protected is true.
+func protectedResources(res []*cloudinary.Resource, protected func(publicID string) bool) []*cloudinary.Resource {
+	filtered := make([]*cloudinary.Resource, 0)
+	for _, r := range res {
+		if protected(r.PublicId) {
+			filtered = append(filtered, r)
+		}
+	}
+	return filtered
+}
+
+// missingIDs returns the public ids without a matching resource.
+func missingIDs(ids []string, res []*cloudinary.Resource) []string {
+	found := make(map[string]bool, len(res))
+	for _, r := range res {
+		found[r.PublicId] = true
+	}
+	missing := make([]string, 0)
+	for _, id := range ids {
+		if !found[id] {
+			missing = append(missing, id)
+		}
+	}
+	return missing
 }
 
 func printResources(res []*cloudinary.Resource, err error) {
 	if err != nil {
 		fail(err.Error())
 	}
+	if strings.HasPrefix(optOutput, outputTemplate) {
+		if err := writeTemplate(out, strings.TrimPrefix(optOutput, outputTemplate), res); err != nil {
+			fail(err.Error())
+		}
+		return
+	}
+	if len(optFields) > 0 {
+		printFields(res, optFields)
+		return
+	}
+	switch optOutput {
+	case outputJSON:
+		if err := writeJSON(out, res); err != nil {
+			fail(err.Error())
+		}
+		return
+	case outputCSV:
+		if err := writeCSV(out, res); err != nil {
+			fail(err.Error())
+		}
+		return
+	}
+	if len(res) == 0 {
+		info("No resource found.")
+		return
+	}
+	if optShowTags {
+		fmt.Fprintf(out, "%-30s %-10s %-5s %10s %s\n", "public_id", "Version", "Type", "Size", "Tags")
+	} else {
+		fmt.Fprintf(out, "%-30s %-10s %-5s %s\n", "public_id", "Version", "Type", "Size")
+	}
+	fmt.Fprintln(out, strings.Repeat("-", 70))
+	for _, r := range res {
+		if optShowTags {
+			fmt.Fprintf(out, "%-30s %d %s %10d %s\n", r.PublicId, r.Version, r.ResourceType, r.Size, strings.Join(r.Tags, ","))
+		} else {
+			fmt.Fprintf(out, "%-30s %d %s %10d\n", r.PublicId, r.Version, r.ResourceType, r.Size)
+		}
+	}
+}
+
+// printFields prints only the given fields of resources, as a table,
+// JSON or CSV.
+func printFields(res []*cloudinary.Resource, fields []string) {
+	switch optOutput {
+	case outputJSON:
+		if err := writeJSONFields(out, res, fields); err != nil {
+			fail(err.Error())
+		}
+		return
+	case outputCSV:
+		if err := writeCSVFields(out, res, fields); err != nil {
+			fail(err.Error())
+		}
+		return
+	}
 	if len(res) == 0 {
-		fmt.Println("No resource found.")
+		info("No resource found.")
 		return
 	}
-	fmt.Printf("%-30s %-10s %-5s %s\n", "public_id", "Version", "Type", "Size")
-	fmt.Println(strings.Repeat("-", 70))
+	row := func(values []string) {
+		for i, v := range values[:len(values)-1] {
+			width := 12
+			if fields[i] == "public_id" {
+				width = 30
+			}
+			fmt.Fprintf(out, "%-*s ", width, v)
+		}
+		fmt.Fprintln(out, values[len(values)-1])
+	}
+	row(fields)
+	fmt.Fprintln(out, strings.Repeat("-", 70))
 	for _, r := range res {
-		fmt.Printf("%-30s %d %s %10d\n", r.PublicId, r.Version, r.ResourceType, r.Size)
+		values := make([]string, len(fields))
+		for i, f := range fields {
+			values[i] = formatField(fieldValue(r, f))
+		}
+		row(values)
 	}
 }
 
@@ -74,20 +453,81 @@ func printResourceDetails(res *cloudinary.ResourceDetails, err error) {
 		fail(err.Error())
 	}
 	if res == nil || len(res.PublicId) == 0 {
-		fmt.Println("No resource details found.")
+		info("No resource details found.")
 		return
 	}
-	fmt.Printf("%-30s %-6s %-10s %-5s %-8s %-6s %-6s %-s\n", "public_id", "Format", "Version", "Type", "Size(KB)", "Width", "Height", "Url")
-	fmt.Printf("%-30s %-6s %-10d %-5s %-8d %-6d %-6d %-s\n", res.PublicId, res.Format, res.Version, res.ResourceType, res.Size/1024, res.Width, res.Height, res.Url)
+	fmt.Fprintf(out, "%-30s %-6s %-10s %-5s %-8s %-6s %-6s %-s\n", "public_id", "Format", "Version", "Type", "Size(KB)", "Width", "Height", "Url")
+	fmt.Fprintf(out, "%-30s %-6s %-10d %-5s %-8d %-6s %-6s %-s\n", res.PublicId, orDash(res.Format), res.Version, res.ResourceType, res.Size/1024, dimension(res.Width), dimension(res.Height), res.Url)
+
+	fmt.Fprintln(out)
 
-	fmt.Println()
+	if c := res.Coordinates; c != nil && (len(c.Face) > 0 || len(c.Custom) > 0) {
+		fmt.Fprintf(out, "%-25s %s\n", "Face coordinates:", formatCoordinates(c.Face))
+		fmt.Fprintf(out, "%-25s %s\n", "Custom coordinates:", formatCoordinates(c.Custom))
+		fmt.Fprintln(out)
+	}
+
+	if len(res.Metadata) > 0 {
+		fmt.Fprintf(out, "%-25s %s\n", "Metadata:", formatMetadata(res.Metadata))
+		fmt.Fprintln(out)
+	}
 
 	for i, d := range res.Derived {
 		if i == 0 {
-			fmt.Printf("%-25s %-8s %-s\n", "transformation", "Size", "Url")
+			fmt.Fprintf(out, "%-25s %-8s %-s\n", "transformation", "Size", "Url")
+		}
+		fmt.Fprintf(out, "%-25s %-8d %-s\n", d.Transformation, d.Size, d.Url)
+	}
+}
+
+// formatMetadata formats structured metadata fields as id=value, comma
+// separated. The values of set fields are joined with semicolons.
+func formatMetadata(m cloudinary.Metadata) string {
+	fields := make(map[string]string, len(m))
+	for id, v := range m {
+		if list, ok := v.([]interface{}); ok {
+			values := make([]string, len(list))
+			for i, e := range list {
+				values[i] = fmt.Sprint(e)
+			}
+			fields[id] = strings.Join(values, ";")
+			continue
+		}
+		fields[id] = fmt.Sprint(v)
+	}
+	return formatContext(fields)
+}
+
+// orDash returns s, or "-" if empty.
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// dimension formats a width or height, "-" for resources without any.
+func dimension(n int) string {
+	if n == 0 {
+		return "-"
+	}
+	return strconv.Itoa(n)
+}
+
+// formatCoordinates formats regions the way they are given at upload time.
+func formatCoordinates(regions [][]int) string {
+	if len(regions) == 0 {
+		return "-"
+	}
+	r := make([]string, len(regions))
+	for i, region := range regions {
+		v := make([]string, len(region))
+		for j, n := range region {
+			v[j] = strconv.Itoa(n)
 		}
-		fmt.Printf("%-25s %-8d %-s\n", d.Transformation, d.Size, d.Url)
+		r[i] = strings.Join(v, ",")
 	}
+	return strings.Join(r, "|")
 }
 
 func fail(msg string) {
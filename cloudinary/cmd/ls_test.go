@@ -0,0 +1,281 @@
+// Copyright © 2017 Jimmy Song
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	cloudinary "github.com/rootsongjc/cloudinary-go"
+)
+
+func TestPrintResourcesTags(t *testing.T) {
+	res := []*cloudinary.Resource{
+		{PublicId: "images/logo", Version: 1, ResourceType: "image", Size: 10, Tags: []string{"brand", "hero"}},
+	}
+	defer func() { optShowTags = false }()
+
+	out := captureOutput(func() { printResources(res, nil) })
+	if strings.Contains(out, "Tags") || strings.Contains(out, "brand") {
+		t.Errorf("tags column should be hidden by default, got %q", out)
+	}
+
+	optShowTags = true
+	out = captureOutput(func() { printResources(res, nil) })
+	if !strings.Contains(out, "Tags") || !strings.Contains(out, "brand,hero") {
+		t.Errorf("tags column should be shown with --show-tags, got %q", out)
+	}
+}
+
+func TestPrintResourcesJSON(t *testing.T) {
+	res := []*cloudinary.Resource{{PublicId: "images/logo", Version: 1, ResourceType: "image", Size: 10}}
+	defer func() { optOutput = outputTable }()
+
+	optOutput = outputJSON
+	var got []*cloudinary.Resource
+	if err := json.Unmarshal([]byte(captureOutput(func() { printResources(res, nil) })), &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].PublicId != "images/logo" {
+		t.Errorf("wrong JSON output %+v", got)
+	}
+}
+
+func TestPrintResourcesFields(t *testing.T) {
+	res := []*cloudinary.Resource{{PublicId: "images/logo", Format: "png", Version: 1, ResourceType: "image", Size: 10, Width: 20}}
+	defer func() { optOutput, optFields = outputTable, nil }()
+
+	optOutput, optFields = outputJSON, []string{"public_id", "bytes"}
+	var got []map[string]interface{}
+	if err := json.Unmarshal([]byte(captureOutput(func() { printResources(res, nil) })), &got); err != nil {
+		t.Fatal(err)
+	}
+	want := []map[string]interface{}{{"public_id": "images/logo", "bytes": float64(10)}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("wrong JSON output %v, want %v", got, want)
+	}
+
+	optOutput = outputCSV
+	if got := captureOutput(func() { printResources(res, nil) }); got != "public_id,bytes\nimages/logo,10\n" {
+		t.Errorf("wrong CSV output %q", got)
+	}
+
+	optOutput = outputTable
+	if got := captureOutput(func() { printResources(res, nil) }); strings.Contains(got, "png") || !strings.Contains(got, "images/logo") {
+		t.Errorf("wrong table output %q", got)
+	}
+	if err := checkFields([]string{"public_id", "size"}); err == nil {
+		t.Error("expected an unknown field error")
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	res := []*cloudinary.Resource{
+		{PublicId: "images/a,b", ResourceType: "image", Format: "png", Version: 1, Size: 10, Width: 20, Height: 30, Url: "http://x/a,b.png"},
+		{PublicId: `say "hi"`, ResourceType: "raw", Version: 2, Size: 5, Url: "http://x/hi"},
+	}
+	var buf bytes.Buffer
+	if err := writeCSV(&buf, res); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid CSV: %v", err)
+	}
+	exp := [][]string{
+		{"public_id", "type", "format", "version", "size", "width", "height", "url"},
+		{"images/a,b", "image", "png", "1", "10", "20", "30", "http://x/a,b.png"},
+		{`say "hi"`, "raw", "", "2", "5", "0", "0", "http://x/hi"},
+	}
+	if !reflect.DeepEqual(rows, exp) {
+		t.Errorf("wrong CSV rows. Expect %q, got %q", exp, rows)
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeJSON(&buf, nil); err != nil {
+		t.Fatal(err)
+	}
+	if strings.TrimSpace(buf.String()) != "[]" {
+		t.Errorf("empty list should be encoded as [], got %q", buf.String())
+	}
+	buf.Reset()
+	res := []*cloudinary.Resource{{PublicId: "a,b", Version: 3}}
+	if err := writeJSON(&buf, res); err != nil {
+		t.Fatal(err)
+	}
+	var got []*cloudinary.Resource
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].PublicId != "a,b" || got[0].Version != 3 {
+		t.Errorf("wrong JSON output: %s", buf.String())
+	}
+}
+
+func TestCheckOutputFormat(t *testing.T) {
+	for _, f := range []string{"table", "json", "csv"} {
+		if err := checkOutputFormat(f); err != nil {
+			t.Errorf("%s: %v", f, err)
+		}
+	}
+	if err := checkOutputFormat("xml"); err == nil {
+		t.Error("xml should be rejected")
+	}
+}
+
+func TestPrintRawResourceDetails(t *testing.T) {
+	res := &cloudinary.ResourceDetails{PublicId: "js/app.js", Version: 1, ResourceType: "raw", Size: 2048, Url: "http://x/js/app.js"}
+	out := captureOutput(func() { printResourceDetails(res, nil) })
+	lines := strings.Split(out, "\n")
+	fields := strings.Fields(lines[1])
+	exp := []string{"js/app.js", "-", "1", "raw", "2", "-", "-", "http://x/js/app.js"}
+	if !reflect.DeepEqual(fields, exp) {
+		t.Errorf("wrong raw details. Expect %v, got %v", exp, fields)
+	}
+}
+
+func TestPrintMetadata(t *testing.T) {
+	res := &cloudinary.ResourceDetails{PublicId: "shoe", Version: 1, ResourceType: "image", Metadata: cloudinary.Metadata{
+		"sku": "A1", "stock": 12.0, "colors": []interface{}{"red", "blue"},
+	}}
+	out := captureOutput(func() { printResourceDetails(res, nil) })
+	if exp := "Metadata:                 colors=red;blue, sku=A1, stock=12\n"; !strings.Contains(out, exp) {
+		t.Errorf("expect %q in details, got %q", exp, out)
+	}
+}
+
+func TestFetchInventoriesConcurrently(t *testing.T) {
+	var mu sync.Mutex
+	inflight := 0
+	both := make(chan struct{})
+	fetch := func(rtype cloudinary.ResourceType) ([]*cloudinary.Resource, error) {
+		mu.Lock()
+		inflight++
+		if inflight == 2 {
+			close(both)
+		}
+		mu.Unlock()
+		// Only returns once both fetches have been issued
+		select {
+		case <-both:
+		case <-time.After(5 * time.Second):
+			return nil, errors.New("fetches are not concurrent")
+		}
+		if rtype == cloudinary.RawType {
+			return nil, errors.New("raw listing failed")
+		}
+		return []*cloudinary.Resource{{PublicId: "images/logo"}}, nil
+	}
+
+	invs := fetchInventories(fetch)
+	if len(invs) != 2 {
+		t.Fatalf("expected 2 inventories, got %d", len(invs))
+	}
+	raw, img := invs[0], invs[1]
+	if raw.caption != "Raw resources:" || raw.err == nil || raw.err.Error() != "raw listing failed" {
+		t.Errorf("raw inventory should come first with its error, got %+v", raw)
+	}
+	if img.caption != "Images:" || img.err != nil || len(img.res) != 1 || img.res[0].PublicId != "images/logo" {
+		t.Errorf("image inventory should be listed despite the raw error, got %+v", img)
+	}
+}
+
+func TestWithFormat(t *testing.T) {
+	res := []*cloudinary.Resource{
+		{PublicId: "logo", Format: "svg"},
+		{PublicId: "cover", Format: "jpg"},
+		{PublicId: "icon", Format: "svg"},
+		{PublicId: "notes"},
+	}
+	var ids []string
+	for _, r := range withFormat(res, ".SVG") {
+		ids = append(ids, r.PublicId)
+	}
+	if exp := []string{"logo", "icon"}; !reflect.DeepEqual(ids, exp) {
+		t.Errorf("expect %v, got %v", exp, ids)
+	}
+	if f := withFormat(res, "pdf"); len(f) != 0 {
+		t.Errorf("no pdf expected, got %v", f)
+	}
+}
+
+func TestWriteTemplate(t *testing.T) {
+	res := []*cloudinary.Resource{
+		{PublicId: "images/logo", Size: 1536, Tags: []string{"brand", "hero"}},
+		{PublicId: "js/app.js", Size: 100},
+		{PublicId: "video/intro", Size: 5 * 1024 * 1024},
+	}
+	tests := []struct {
+		text, expect string
+	}{
+		{"{{.PublicId}} {{.Size}}", "images/logo 1536\njs/app.js 100\nvideo/intro 5242880\n"},
+		{"{{.PublicId}}: {{humanBytes .Size}}", "images/logo: 1.5 KB\njs/app.js: 100 B\nvideo/intro: 5.0 MB\n"},
+		{`{{.PublicId}}{{if .Tags}} [{{join .Tags ","}}]{{end}}`, "images/logo [brand,hero]\njs/app.js\nvideo/intro\n"},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		if err := writeTemplate(&buf, tt.text, res); err != nil {
+			t.Errorf("%s: %s", tt.text, err)
+			continue
+		}
+		if buf.String() != tt.expect {
+			t.Errorf("%s: expect %q, got %q", tt.text, tt.expect, buf.String())
+		}
+	}
+
+	if err := checkOutputFormat("template={{.PublicId}"); err == nil || !strings.Contains(err.Error(), "invalid output template") {
+		t.Errorf("a bad template should be reported, got %v", err)
+	}
+	if err := checkOutputFormat("template={{.PublicId}} {{humanBytes .Size}}"); err != nil {
+		t.Errorf("valid template rejected: %s", err)
+	}
+	if err := writeTemplate(ioutil.Discard, "{{.Unknown}}", res); err == nil {
+		t.Error("unknown fields should be reported")
+	}
+}
+
+func TestProtectedResources(t *testing.T) {
+	s, err := cloudinary.NewService("cloudname", "key", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.KeepFiles(`^(brand/|legal\.pdf$)`); err != nil {
+		t.Fatal(err)
+	}
+	res := []*cloudinary.Resource{
+		{PublicId: "brand/logo"},
+		{PublicId: "images/brand/logo"},
+		{PublicId: "legal.pdf"},
+		{PublicId: "legal.pdf.bak"},
+		{PublicId: "brand/colors.css"},
+	}
+	var ids []string
+	for _, r := range protectedResources(res, s.Protected) {
+		ids = append(ids, r.PublicId)
+	}
+	if exp := []string{"brand/logo", "legal.pdf", "brand/colors.css"}; !reflect.DeepEqual(ids, exp) {
+		t.Errorf("expect %v, got %v", exp, ids)
+	}
+}
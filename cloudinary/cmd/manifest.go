@@ -0,0 +1,91 @@
+// Copyright © 2017 Jimmy Song
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	cloudinary "github.com/rootsongjc/cloudinary-go"
+)
+
+// assetManifest maps local file paths to delivery URLs, for bundlers to
+// rewrite references to uploaded assets. It is safe for concurrent use.
+type assetManifest struct {
+	mu   sync.Mutex
+	urls map[string]string
+}
+
+func newAssetManifest() *assetManifest {
+	return &assetManifest{urls: make(map[string]string)}
+}
+
+// add records the delivery URL of res uploaded from path. Resources
+// without URL, e.g. pending async uploads, are ignored.
+func (m *assetManifest) add(path string, res *cloudinary.Resource) {
+	if res == nil {
+		return
+	}
+	u := res.SecureUrl
+	if u == "" {
+		u = res.Url
+	}
+	if u == "" {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.urls[filepath.ToSlash(filepath.Clean(path))] = u
+}
+
+// readManifest reads the manifest at path to merge new uploads into. A
+// missing file is not an error: an empty manifest is returned.
+func readManifest(path string) (*assetManifest, error) {
+	m := newAssetManifest()
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &m.urls); err != nil {
+		return nil, fmt.Errorf("manifest %s: %s", path, err.Error())
+	}
+	if m.urls == nil {
+		m.urls = make(map[string]string)
+	}
+	return m, nil
+}
+
+// writeManifest atomically replaces the manifest at path. Paths are
+// sorted so that the file diffs nicely between builds.
+func writeManifest(path string, m *assetManifest) error {
+	m.mu.Lock()
+	data, err := json.MarshalIndent(m.urls, "", "  ")
+	m.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, append(data, '\n'), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,113 @@
+// Copyright © 2017 Jimmy Song
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	cloudinary "github.com/rootsongjc/cloudinary-go"
+)
+
+func TestWriteManifest(t *testing.T) {
+	dir, err := ioutil.TempDir("", "manifest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "assets.json")
+
+	m := newAssetManifest()
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			m.add(fmt.Sprintf("./img/%d.png", i), &cloudinary.Resource{SecureUrl: fmt.Sprintf("https://cdn/%d.png", i)})
+		}(i)
+	}
+	wg.Wait()
+	// Pending async uploads have no URL yet
+	m.add("img/pending.png", &cloudinary.Resource{})
+	if err := writeManifest(path, m); err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := "{\n"
+	for i := 0; i < 10; i++ {
+		exp += fmt.Sprintf("  \"img/%d.png\": \"https://cdn/%d.png\"", i, i)
+		if i < 9 {
+			exp += ","
+		}
+		exp += "\n"
+	}
+	exp += "}\n"
+	if string(data) != exp {
+		t.Errorf("expect\n%s\ngot\n%s", exp, data)
+	}
+}
+
+func TestMergeManifest(t *testing.T) {
+	dir, err := ioutil.TempDir("", "manifest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "assets.json")
+
+	m, err := readManifest(path)
+	if err != nil {
+		t.Fatalf("a missing manifest should not be an error: %v", err)
+	}
+	m.add("css/app.css", &cloudinary.Resource{Url: "http://cdn/v1/app.css"})
+	m.add("js/app.js", &cloudinary.Resource{SecureUrl: "https://cdn/v1/app.js"})
+	if err := writeManifest(path, m); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err = readManifest(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.add("js/app.js", &cloudinary.Resource{SecureUrl: "https://cdn/v2/app.js"})
+	if err := writeManifest(path, m); err != nil {
+		t.Fatal(err)
+	}
+	got, err := readManifest(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := map[string]string{"css/app.css": "http://cdn/v1/app.css", "js/app.js": "https://cdn/v2/app.js"}
+	if len(got.urls) != len(exp) {
+		t.Fatalf("expect %v, got %v", exp, got.urls)
+	}
+	for k, v := range exp {
+		if got.urls[k] != v {
+			t.Errorf("%s: expect %s, got %s", k, v, got.urls[k])
+		}
+	}
+
+	ioutil.WriteFile(path, []byte("[]"), 0644)
+	if _, err := readManifest(path); err == nil {
+		t.Error("a manifest which is not an object should be reported")
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright © 2017 Jimmy Song
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// mappingsCmd represents the mappings command
+var mappingsCmd = &cobra.Command{
+	Use:   "mappings",
+	Short: "Manage upload mappings",
+}
+
+var mappingsLsCmd = &cobra.Command{
+	Use:   "ls",
+	Short: "List upload mappings",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		mappings, err := service.UploadMappings()
+		if err != nil {
+			perror(err)
+		}
+		if len(mappings) == 0 {
+			info("No mapping found.")
+			return
+		}
+		for _, m := range mappings {
+			fmt.Fprintf(out, "%-30s %s\n", m.Folder, m.Template)
+		}
+	},
+}
+
+var mappingsCreateCmd = &cobra.Command{
+	Use:   "create <folder> <template>",
+	Short: "Map a folder to a remote URL prefix",
+	Args:  cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		step(fmt.Sprintf("Mapping %s to %s", args[0], args[1]))
+		if err := service.CreateUploadMapping(args[0], args[1]); err != nil {
+			perror(err)
+		}
+	},
+}
+
+var mappingsRmCmd = &cobra.Command{
+	Use:   "rm <folder>",
+	Short: "Remove the upload mapping of a folder",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		step(fmt.Sprintf("Removing mapping %s", args[0]))
+		if err := service.DeleteUploadMapping(args[0]); err != nil {
+			perror(err)
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(mappingsCmd)
+	mappingsCmd.AddCommand(mappingsLsCmd, mappingsCreateCmd, mappingsRmCmd)
+}
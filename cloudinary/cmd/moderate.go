@@ -0,0 +1,90 @@
+// Copyright © 2017 Jimmy Song
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	cloudinary "github.com/rootsongjc/cloudinary-go"
+	"github.com/spf13/cobra"
+)
+
+var optModerationKind, optModerationStatus string
+
+// moderateCmd represents the moderate command
+var moderateCmd = &cobra.Command{
+	Use:   "moderate",
+	Short: "Review files queued for moderation",
+}
+
+var moderateListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List files by moderation kind and status",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		rtype, err := parseResourceType(optType)
+		if err != nil {
+			perror(err)
+		}
+		printResources(service.ModeratedResources(optModerationKind, optModerationStatus, rtype))
+	},
+}
+
+var moderateApproveCmd = &cobra.Command{
+	Use:   "approve",
+	Short: "Approve a file pending manual moderation",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		updateModeration(cloudinary.ModerationApproved)
+	},
+}
+
+var moderateRejectCmd = &cobra.Command{
+	Use:   "reject",
+	Short: "Reject a file pending manual moderation",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		updateModeration(cloudinary.ModerationRejected)
+	},
+}
+
+// updateModeration sets the moderation status of the file given with
+// -i or -r.
+func updateModeration(status string) {
+	if optRaw == "" && optImg == "" {
+		fail("Missing -i or -r option.")
+	}
+	rtype := cloudinary.ImageType
+	publicID := composePublicID(optImg)
+	if optRaw != "" {
+		rtype = cloudinary.RawType
+		publicID = composePublicID(optRaw)
+	}
+	step(fmt.Sprintf("Setting moderation status of %s to %s", publicID, status))
+	if optSimulate {
+		return
+	}
+	if err := service.UpdateModeration(publicID, status, rtype); err != nil {
+		perror(err)
+	}
+}
+
+func init() {
+	RootCmd.AddCommand(moderateCmd)
+	moderateCmd.AddCommand(moderateListCmd, moderateApproveCmd, moderateRejectCmd)
+	moderateListCmd.Flags().StringVar(&optModerationKind, "kind", "manual", "moderation kind, e.g. manual, webpurify or aws_rek")
+	moderateListCmd.Flags().StringVar(&optModerationStatus, "status", cloudinary.ModerationPending, "moderation status: pending, approved or rejected")
+	moderateListCmd.Flags().StringVar(&optType, "type", "image", "resource type: image, raw or video")
+}
@@ -0,0 +1,211 @@
+// Copyright © 2017 Jimmy Song
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	cloudinary "github.com/rootsongjc/cloudinary-go"
+)
+
+// Output formats of resource listings.
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+	outputCSV   = "csv"
+	// Followed by a text/template executed for each resource, e.g.
+	// "template={{.PublicId}} {{humanBytes .Size}}"
+	outputTemplate = "template="
+)
+
+// checkOutputFormat returns an error if format is not a known output format.
+func checkOutputFormat(format string) error {
+	switch format {
+	case outputTable, outputJSON, outputCSV:
+		return nil
+	}
+	if strings.HasPrefix(format, outputTemplate) {
+		_, err := parseOutputTemplate(strings.TrimPrefix(format, outputTemplate))
+		return err
+	}
+	return fmt.Errorf("unknown output format %q, must be one of table, json, csv or template=<template>", format)
+}
+
+// templateFuncs are the functions available to output templates.
+var templateFuncs = template.FuncMap{
+	"humanBytes": humanBytes,
+	"join":       strings.Join,
+}
+
+// parseOutputTemplate parses a text/template output format.
+func parseOutputTemplate(text string) (*template.Template, error) {
+	t, err := template.New("output").Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid output template: %s", err)
+	}
+	return t, nil
+}
+
+// writeTemplate executes the text template for each resource, writing
+// one line per resource.
+func writeTemplate(w io.Writer, text string, res []*cloudinary.Resource) error {
+	t, err := parseOutputTemplate(text)
+	if err != nil {
+		return err
+	}
+	for _, r := range res {
+		if err := t.Execute(w, r); err != nil {
+			return fmt.Errorf("output template: %s", err)
+		}
+		fmt.Fprintln(w)
+	}
+	return nil
+}
+
+// humanBytes formats a size in bytes with a binary unit, e.g. "1.5 KB".
+func humanBytes(n int) string {
+	if n < 1024 {
+		return fmt.Sprintf("%d B", n)
+	}
+	size, unit := float64(n)/1024, 0
+	for size >= 1024 && unit < 3 {
+		size /= 1024
+		unit++
+	}
+	return fmt.Sprintf("%.1f %s", size, []string{"KB", "MB", "GB", "TB"}[unit])
+}
+
+// writeJSON writes resources as an indented JSON array.
+func writeJSON(w io.Writer, res []*cloudinary.Resource) error {
+	if res == nil {
+		res = []*cloudinary.Resource{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(res)
+}
+
+// writeCSV writes a header row then one row per resource.
+func writeCSV(w io.Writer, res []*cloudinary.Resource) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"public_id", "type", "format", "version", "size", "width", "height", "url"})
+	for _, r := range res {
+		cw.Write([]string{
+			r.PublicId,
+			r.ResourceType,
+			r.Format,
+			strconv.Itoa(r.Version),
+			strconv.Itoa(r.Size),
+			strconv.Itoa(r.Width),
+			strconv.Itoa(r.Height),
+			r.Url,
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// fieldValue returns the value of the name field of r, see
+// cloudinary.ResourceFields.
+func fieldValue(r *cloudinary.Resource, name string) interface{} {
+	switch name {
+	case "public_id":
+		return r.PublicId
+	case "format":
+		return r.Format
+	case "version":
+		return r.Version
+	case "resource_type":
+		return r.ResourceType
+	case "bytes":
+		return r.Size
+	case "width":
+		return r.Width
+	case "height":
+		return r.Height
+	case "url":
+		return r.Url
+	case "secure_url":
+		return r.SecureUrl
+	case "tags":
+		return r.Tags
+	case "created_at":
+		return r.CreatedAt
+	}
+	return nil
+}
+
+// formatField formats a field value for table and CSV outputs.
+func formatField(v interface{}) string {
+	switch v := v.(type) {
+	case []string:
+		return strings.Join(v, ",")
+	case time.Time:
+		if v.IsZero() {
+			return ""
+		}
+		return v.Format(time.RFC3339)
+	}
+	return fmt.Sprint(v)
+}
+
+// writeJSONFields writes resources as an indented JSON array of
+// objects holding only fields.
+func writeJSONFields(w io.Writer, res []*cloudinary.Resource, fields []string) error {
+	docs := make([]map[string]interface{}, len(res))
+	for i, r := range res {
+		docs[i] = make(map[string]interface{}, len(fields))
+		for _, f := range fields {
+			docs[i][f] = fieldValue(r, f)
+		}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(docs)
+}
+
+// writeCSVFields writes a header row of fields then one row per
+// resource.
+func writeCSVFields(w io.Writer, res []*cloudinary.Resource, fields []string) error {
+	cw := csv.NewWriter(w)
+	cw.Write(fields)
+	for _, r := range res {
+		row := make([]string, len(fields))
+		for i, f := range fields {
+			row[i] = formatField(fieldValue(r, f))
+		}
+		cw.Write(row)
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// checkFields returns an error if a field is not one of
+// cloudinary.ResourceFields.
+func checkFields(fields []string) error {
+	for _, f := range fields {
+		if !cloudinary.IsResourceField(f) {
+			return fmt.Errorf("unknown field %q, must be one of %s", f, strings.Join(cloudinary.ResourceFields, ", "))
+		}
+	}
+	return nil
+}
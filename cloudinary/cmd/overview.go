@@ -0,0 +1,52 @@
+// Copyright © 2017 Jimmy Song
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var optDepth int
+
+// overviewCmd represents the overview command
+var overviewCmd = &cobra.Command{
+	Use:   "overview",
+	Short: "Print the number and size of files per folder",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		stats, err := service.FolderSummaryDepth(optDepth)
+		if err != nil {
+			perror(err)
+		}
+		if len(stats) == 0 {
+			info("No folder found.")
+			return
+		}
+		for _, st := range stats {
+			size := humanBytes(int(st.TotalBytes))
+			if st.Truncated {
+				size = ">" + size
+			}
+			fmt.Fprintf(out, "%-40s %8d %12s\n", st.Name, st.AssetCount, size)
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(overviewCmd)
+	overviewCmd.Flags().IntVar(&optDepth, "depth", 1, "folder levels to summarize, 1 for root folders only")
+}
@@ -0,0 +1,70 @@
+// Copyright © 2017 Jimmy Song
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+// progressCounter displays the number of resources fetched so far on a
+// single, constantly updated line. Nothing is displayed if everything
+// fits in a single page. It is safe for concurrent listings.
+type progressCounter struct {
+	mu    sync.Mutex
+	w     io.Writer
+	width int // Length of the last line written
+}
+
+// update is meant to be used as a Service.ListProgress() function.
+func (p *progressCounter) update(fetched int, done bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if done {
+		p.clear()
+		return
+	}
+	line := fmt.Sprintf("fetched %d resources...", fetched)
+	fmt.Fprintf(p.w, "\r%s", line)
+	p.width = len(line)
+}
+
+// clear erases the counter line, if any.
+func (p *progressCounter) clear() {
+	if p.width == 0 {
+		return
+	}
+	fmt.Fprintf(p.w, "\r%s\r", strings.Repeat(" ", p.width))
+	p.width = 0
+}
+
+// isTerminal reports whether f is a terminal.
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// showListProgress displays a fetch counter on stderr while listing
+// resources, unless quiet or not writing to a terminal.
+func showListProgress() {
+	if optQuiet || !isTerminal(os.Stdout) || !isTerminal(os.Stderr) {
+		return
+	}
+	p := &progressCounter{w: os.Stderr}
+	service.ListProgress(p.update)
+}
@@ -0,0 +1,40 @@
+// Copyright © 2017 Jimmy Song
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestProgressCounter(t *testing.T) {
+	var buf bytes.Buffer
+	p := &progressCounter{w: &buf}
+	p.update(10, true)
+	if buf.Len() != 0 {
+		t.Errorf("nothing should be displayed for a single page, got %q", buf.String())
+	}
+
+	p.update(2048, false)
+	p.update(4096, false)
+	if exp := "\rfetched 2048 resources...\rfetched 4096 resources..."; buf.String() != exp {
+		t.Errorf("expect one update per page. Expect %q, got %q", exp, buf.String())
+	}
+	buf.Reset()
+	p.update(5000, true)
+	if exp := "\r                         \r"; buf.String() != exp {
+		t.Errorf("counter should be cleared when done, got %q", buf.String())
+	}
+}
@@ -15,39 +15,236 @@
 package cmd
 
 import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	cloudinary "github.com/rootsongjc/cloudinary-go"
 	"github.com/spf13/cobra"
 )
 
+var optUpload cloudinary.UploadOptions
+var optWait bool
+var optPollInterval, optPollTimeout time.Duration
+var optTar string
+var optCleanup bool
+var optManifest string
+var optMergeManifest bool
+var optUploadTag []string
+var optUploadTags []string
+var optUploadMetadata []string
+
 // putCmd represents the up command
 var putCmd = &cobra.Command{
-	Use:   "put",
-	Short: "Upload file",
+	Use:     "put [file]",
+	Aliases: []string{"upload"},
+	Short:   "Upload file",
 	Run: func(cmd *cobra.Command, args []string) {
 		if optPath != "" {
 			settings.PrependPath = optPath
 		}
+		applyDefaultType(args)
 		if optRaw == "" && optImg == "" {
 			fail("Missing -i or -r option.")
 		}
-		if optRaw != "" {
+		if optPollInterval <= 0 || optPollTimeout < 0 {
+			fail("--poll-interval must be positive and --poll-timeout can't be negative.")
+		}
+		optUpload.Tags = append(optUploadTag, optUploadTags...)
+		metadata, err := parseContext(optUploadMetadata)
+		if err != nil {
+			fail("--metadata: " + err.Error())
+		}
+		optUpload.Metadata = metadata
+		connectDatabase(true)
+		service.SetCleanupOnCancel(optCleanup)
+		ctx, stop := interruptContext()
+		defer stop()
+		var manifest *assetManifest
+		if optManifest != "" {
+			manifest = newAssetManifest()
+			if optMergeManifest {
+				var err error
+				if manifest, err = readManifest(optManifest); err != nil {
+					perror(err)
+				}
+			}
+		}
+		jobs := new(jobRegistry)
+		service.OnUpload(func(path string, res *cloudinary.Resource) {
+			if manifest != nil {
+				manifest.add(path, res)
+			}
+			if res.JobToken != "" {
+				jobs.add(path, res.JobToken)
+			}
+		})
+		var res *cloudinary.Resource
+		if optTar != "" {
+			if optRaw == "" {
+				fail("--tar requires -r to name the archive.")
+			}
 			publicID := composePublicID(optRaw)
 			printPublicID(publicID)
-			step("Uploading as raw data")
-			if _, err := service.UploadStaticRaw(optRaw, nil, settings.PrependPath); err != nil {
-				perror(err)
+			step(fmt.Sprintf("Uploading %s as a tar archive", optTar))
+			res, err = service.UploadTar(optTar, publicID)
+			if manifest != nil && err == nil {
+				manifest.add(optTar, res)
 			}
+		} else if optRaw != "" {
+			publicID := composePublicID(optRaw)
+			printPublicID(publicID)
+			rtype := uploadType(cmd, cloudinary.RawType)
+			step("Uploading as raw data")
+			res, err = service.UploadContext(ctx, optRaw, nil, settings.PrependPath, false, rtype, &optUpload)
 		} else {
 			publicID := composePublicID(optImg)
 			printPublicID(publicID)
-			step("Uploading as images")
-			if _, err := service.UploadStaticImage(optImg, nil, settings.PrependPath); err != nil {
+			rtype := uploadType(cmd, defaultImageType())
+			if rtype == cloudinary.AutoType {
+				step("Uploading, type detected by Cloudinary")
+			} else {
+				step("Uploading as images")
+			}
+			res, err = service.UploadContext(ctx, optImg, nil, settings.PrependPath, false, rtype, &optUpload)
+		}
+		if manifest != nil {
+			// Also written on errors, with the files uploaded so far
+			if err := writeManifest(optManifest, manifest); err != nil {
+				perror(err)
+			}
+			step("Manifest written to " + optManifest)
+		}
+		if len(jobs.jobs) > 0 {
+			// Followed up with jobs ls, but the one waited for below
+			var waited string
+			if optWait && res != nil {
+				waited = res.JobToken
+			}
+			if err := saveJobs(jobs, waited); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: can't save the pending jobs: %s\n", err.Error())
+			}
+		}
+		if ctx.Err() != nil {
+			m := service.Metrics()
+			step(fmt.Sprintf("Interrupted: %d files uploaded (%d bytes)", m.Uploads, m.BytesUploaded))
+			os.Exit(130)
+		}
+		if err != nil {
+			perror(err)
+		}
+		if res != nil && res.JobToken != "" {
+			step("Upload pending, job token: " + res.JobToken)
+			if !optWait {
+				return
+			}
+			step("Waiting for the upload to complete")
+			if res, err = service.WaitUpload(res.JobToken, optPollInterval, optPollTimeout); err != nil {
 				perror(err)
 			}
+			step("Upload complete: " + res.SecureUrl)
+		}
+		if res != nil && res.Info != nil {
+			for addon, st := range res.Info.BackgroundRemoval {
+				if st == nil {
+					continue
+				}
+				step(fmt.Sprintf("Background removal (%s): %s", addon, st.Status))
+			}
+		}
+		if res != nil && optType == "auto" && res.ResourceType != "" {
+			step("Uploaded as " + res.ResourceType)
+		}
+		if res != nil && res.Phash != "" {
+			step("Perceptual hash: " + res.Phash)
+		}
+		if res != nil && len(res.Tags) > 0 {
+			step("Tags: " + strings.Join(res.Tags, ","))
+		}
+		if res != nil && res.QualityAnalysis != nil {
+			q := res.QualityAnalysis
+			step(fmt.Sprintf("Quality: score %.2f, focus %.2f, contrast %.2f", res.QualityScore, q.Focus, q.Contrast))
+		}
+		if res != nil && res.AccessibilityAnalysis != nil {
+			step(fmt.Sprintf("Colorblind accessibility: score %.2f", res.AccessibilityAnalysis.Score))
+		}
+		if res != nil && len(res.Colors) > 0 {
+			palette := make([]string, len(res.Colors))
+			for i, c := range res.Colors {
+				palette[i] = fmt.Sprintf("%s %.1f%%", c.Hex, c.Percent)
+			}
+			step("Colors: " + strings.Join(palette, ", "))
 		}
 	},
 }
 
 func init() {
 	RootCmd.AddCommand(putCmd)
+	putCmd.Flags().StringVar(&optType, "type", "image", "resource type: image, raw, video, pdf or auto (default depends on -i or -r)")
+	putCmd.Flags().BoolVar(&optUpload.Phash, "phash", false, "compute the perceptual hash of the image")
+	putCmd.Flags().StringVar(&optUpload.FaceCoordinates, "face-coords", "", "face coordinates as x,y,w,h[|x,y,w,h...]")
+	putCmd.Flags().StringVar(&optUpload.CustomCoordinates, "custom-coords", "", "custom coordinates as x,y,w,h[|x,y,w,h...]")
+	putCmd.Flags().BoolVar(&optUpload.Async, "async", false, "process the upload in the background")
+	putCmd.Flags().StringVar(&optTar, "tar", "", "upload this directory as a single gzipped tar archive, named with -r")
+	putCmd.Flags().BoolVar(&optCleanup, "cleanup-partial", false, "on Ctrl-C, delete the file being uploaded in case it was partially stored")
+	putCmd.Flags().StringVar(&optManifest, "manifest-out", "", "write a JSON map of the uploaded local paths to their delivery URLs to this file")
+	putCmd.Flags().BoolVar(&optMergeManifest, "manifest-merge", false, "with --manifest-out, keep the entries of the existing manifest")
+	putCmd.Flags().BoolVar(&optWait, "wait", false, "with --async or --remove-bg, wait for the processing to complete")
+	putCmd.Flags().DurationVar(&optPollInterval, "poll-interval", time.Second, "with --wait, delay before the first status check, doubled after each check up to 30s")
+	putCmd.Flags().DurationVar(&optPollTimeout, "poll-timeout", 0, "with --wait, fail if the processing is still pending after this duration (default wait forever)")
+	putCmd.Flags().StringVar(&optUpload.BackgroundRemoval, "remove-bg", "", "remove the image background with this add-on, e.g. cloudinary_ai")
+	putCmd.Flags().StringArrayVar(&optUploadTag, "tag", nil, "tag the uploaded resource (repeatable)")
+	putCmd.Flags().StringSliceVar(&optUploadTags, "tags", nil, "comma separated list of tags of the uploaded resource")
+	putCmd.Flags().StringArrayVar(&optUploadMetadata, "metadata", nil, "set a structured metadata field as external_id=value (repeatable)")
+	putCmd.Flags().StringVar(&optUpload.Categorization, "categorization", "", "categorization add-ons, e.g. google_tagging")
+	putCmd.Flags().Float64Var(&optUpload.AutoTagging, "auto-tagging", 0, "tag with the categories above this confidence threshold (0 to 1)")
+	putCmd.Flags().StringVar(&optUpload.ContentType, "content-type", "", "content type of the file, e.g. application/pdf (default guessed from the extension)")
+	putCmd.Flags().StringVar(&optUpload.PublicIDPrefix, "id-prefix", "", "public id prefix applied by Cloudinary, e.g. products/ (see README for the difference with --path)")
+	putCmd.Flags().StringVar(&optUpload.UploadPreset, "preset", "", "upload preset to apply")
+	putCmd.Flags().BoolVar(&optUpload.Unsigned, "unsigned", false, "upload without credentials, requires an unsigned --preset")
+	putCmd.Flags().StringVar(&optUpload.Eval, "eval", "", "JavaScript run on the uploaded resource, e.g. \"resource.tags = ['x']\" (signed uploads only)")
+	putCmd.Flags().BoolVar(&optUpload.QualityAnalysis, "quality-analysis", false, "print the quality scores of the image")
+	putCmd.Flags().BoolVar(&optUpload.AccessibilityAnalysis, "accessibility-analysis", false, "print the colorblind accessibility score of the image")
+	putCmd.Flags().BoolVar(&optUpload.Colors, "colors", false, "print the dominant colors of the image")
+	putCmd.Flags().StringVar(&optUpload.OnCollision, "on-collision", cloudinary.CollisionError, "when files of a directory map to the same public id: error, skip or suffix")
+	putCmd.Flags().BoolVar(&optUpload.PreserveStructure, "preserve-structure", false, "upload files to Cloudinary folders mirroring their local directories")
+	putCmd.Flags().StringVar(&optUpload.IDTemplate, "id-template", "", "public id of each file, e.g. \"{dir}/{name}-{hash:8}\" (placeholders: {dir} relative to the uploaded directory, {name}, {ext}, {hash[:N]}, {date}), overrides --path")
+	putCmd.Flags().BoolVar(&optUpload.Compress, "compress", false, "gzip the raw file before upload, it is stored and delivered gzipped (see download --decompress)")
+	putCmd.Flags().BoolVar(&optUpload.DiscardOriginalFilename, "discard-filename", false, "don't store the original file name (it remains part of the public id)")
+	putCmd.Flags().StringArrayVar(&optUpload.Headers, "header", nil, "HTTP header sent on delivery, e.g. \"Cache-Control: max-age=31536000\" (repeatable)")
+}
+
+// uploadType returns the resource type given with --type, def if unset.
+func uploadType(cmd *cobra.Command, def cloudinary.ResourceType) cloudinary.ResourceType {
+	if !cmd.Flags().Changed("type") {
+		return def
+	}
+	rtype, err := parseResourceType(optType)
+	if err != nil {
+		perror(err)
+	}
+	return rtype
+}
 
+// interruptContext returns a context canceled on SIGINT or SIGTERM, and
+// a function to release it.
+func interruptContext() (context.Context, func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		select {
+		case <-sig:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, func() {
+		signal.Stop(sig)
+		cancel()
+	}
 }
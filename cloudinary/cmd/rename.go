@@ -0,0 +1,156 @@
+// Copyright © 2017 Jimmy Song
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var optRenames string
+var optOverwrite bool
+var optOrder bool
+
+// renameCmd represents the rename command
+var renameCmd = &cobra.Command{
+	Use:   "rename",
+	Short: "Rename files listed in a CSV file",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if optRenames == "" {
+			fail("Missing --manifest option.")
+		}
+		rtype, err := parseResourceType(optType)
+		if err != nil {
+			fail(err.Error())
+		}
+		f, err := os.Open(optRenames)
+		if err != nil {
+			perror(err)
+		}
+		renames, err := readRenames(f)
+		f.Close()
+		if err != nil {
+			perror(fmt.Errorf("%s: %s", optRenames, err))
+		}
+		if optOrder {
+			if renames, err = orderRenames(renames); err != nil {
+				perror(err)
+			}
+		}
+		rename := service.Rename
+		if optOverwrite {
+			rename = service.RenameOverwrite
+		}
+		failed := 0
+		for _, r := range renames {
+			if optSimulate {
+				fmt.Fprintf(out, "%s -> %s: simulated\n", r.from, r.to)
+				continue
+			}
+			if err := rename(r.from, r.to, "", rtype); err != nil {
+				fmt.Fprintf(out, "%s -> %s: %s\n", r.from, r.to, err)
+				failed++
+				continue
+			}
+			fmt.Fprintf(out, "%s -> %s: ok\n", r.from, r.to)
+		}
+		if failed > 0 {
+			fail(fmt.Sprintf("%d of %d renames failed.", failed, len(renames)))
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(renameCmd)
+	renameCmd.Flags().StringVar(&optRenames, "manifest", "", "CSV file of from_public_id,to_public_id rows")
+	renameCmd.Flags().StringVar(&optType, "type", "image", "resource type: image or raw")
+	renameCmd.Flags().BoolVar(&optOverwrite, "overwrite", false, "replace files already named to_public_id")
+	renameCmd.Flags().BoolVar(&optOrder, "order", false, "reorder renames so that chains such as a->b, b->c don't clobber files")
+}
+
+// renameRow is a row of a rename manifest.
+type renameRow struct {
+	from, to string
+}
+
+// readRenames reads from_public_id,to_public_id rows, with an optional
+// header row.
+func readRenames(r io.Reader) ([]renameRow, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = 2
+	cr.TrimLeadingSpace = true
+	var renames []renameRow
+	seen := make(map[string]bool)
+	for line := 1; ; line++ {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			return renames, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		from, to := strings.TrimSpace(rec[0]), strings.TrimSpace(rec[1])
+		if line == 1 && from == "from_public_id" && to == "to_public_id" {
+			continue
+		}
+		if from == "" || to == "" {
+			return nil, fmt.Errorf("line %d: empty public id", line)
+		}
+		if seen[from] {
+			return nil, fmt.Errorf("line %d: %s renamed twice", line, from)
+		}
+		seen[from] = true
+		renames = append(renames, renameRow{from, to})
+	}
+}
+
+// orderRenames orders renames so that a file is renamed away before
+// another one takes its public id: a->b, b->c becomes b->c, a->b. The
+// order of the manifest is kept otherwise. Cycles such as a->b, b->a
+// can't be ordered.
+func orderRenames(renames []renameRow) ([]renameRow, error) {
+	pending := make(map[string]bool, len(renames))
+	for _, r := range renames {
+		pending[r.from] = true
+	}
+	ordered := make([]renameRow, 0, len(renames))
+	left := renames
+	for len(left) > 0 {
+		var next []renameRow
+		for _, r := range left {
+			if pending[r.to] && r.to != r.from {
+				next = append(next, r)
+				continue
+			}
+			ordered = append(ordered, r)
+			delete(pending, r.from)
+		}
+		if len(next) == len(left) {
+			ids := make([]string, len(next))
+			for i, r := range next {
+				ids[i] = r.from
+			}
+			return nil, fmt.Errorf("renames of %s form a cycle", strings.Join(ids, ", "))
+		}
+		left = next
+	}
+	return ordered, nil
+}
@@ -0,0 +1,53 @@
+// Copyright © 2017 Jimmy Song
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadRenames(t *testing.T) {
+	renames, err := readRenames(strings.NewReader("from_public_id,to_public_id\nold/logo, new/logo\n\n\"a,b\",c\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := []renameRow{{"old/logo", "new/logo"}, {"a,b", "c"}}
+	if !reflect.DeepEqual(renames, exp) {
+		t.Errorf("expect %v, got %v", exp, renames)
+	}
+	for _, s := range []string{"a,b,c\n", "a\n", "a,\n", "a,b\na,c\n"} {
+		if _, err := readRenames(strings.NewReader(s)); err == nil {
+			t.Errorf("%q should be rejected", s)
+		}
+	}
+}
+
+func TestOrderRenames(t *testing.T) {
+	renames := []renameRow{{"a", "b"}, {"x", "y"}, {"b", "c"}, {"c", "d"}, {"e", "e"}}
+	ordered, err := orderRenames(renames)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := []renameRow{{"x", "y"}, {"c", "d"}, {"e", "e"}, {"b", "c"}, {"a", "b"}}
+	if !reflect.DeepEqual(ordered, exp) {
+		t.Errorf("expect %v, got %v", exp, ordered)
+	}
+	_, err = orderRenames([]renameRow{{"a", "b"}, {"x", "y"}, {"b", "a"}})
+	if err == nil || !strings.Contains(err.Error(), "a, b") {
+		t.Errorf("expect a cycle error on a and b, got %v", err)
+	}
+}
@@ -0,0 +1,53 @@
+// Copyright © 2017 Jimmy Song
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	cloudinary "github.com/rootsongjc/cloudinary-go"
+	"github.com/spf13/cobra"
+)
+
+// restoreCmd represents the restore command
+var restoreCmd = &cobra.Command{
+	Use:   "restore",
+	Short: "Roll a file back to a backed up version",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if optRaw == "" && optImg == "" {
+			fail("Missing -i or -r option.")
+		}
+		if optVersion <= 0 {
+			fail("Missing --version option, see the versions command.")
+		}
+		rtype := cloudinary.ImageType
+		publicID := composePublicID(optImg)
+		if optRaw != "" {
+			rtype = cloudinary.RawType
+			publicID = composePublicID(optRaw)
+		}
+		printPublicID(publicID)
+		step(fmt.Sprintf("Restoring version %d", optVersion))
+		if err := service.RestoreVersion(publicID, optVersion, rtype); err != nil {
+			perror(err)
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(restoreCmd)
+	restoreCmd.Flags().IntVar(&optVersion, "version", 0, "backed up version to restore")
+}
@@ -15,7 +15,14 @@
 package cmd
 
 import (
+	"bufio"
+	"encoding/json"
 	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	cloudinary "github.com/rootsongjc/cloudinary-go"
 	"github.com/spf13/cobra"
@@ -23,36 +30,214 @@ import (
 
 // rmCmd represents the rm command
 var rmCmd = &cobra.Command{
-	Use:   "rm",
+	Use:   "rm [public id]",
 	Short: "Remove file",
 	Run: func(cmd *cobra.Command, args []string) {
+		if optOutput != outputTable && optOutput != outputJSON {
+			fail("--output must be table or json.")
+		}
+		if optOlderThan != "" {
+			deleteOlderThan()
+			return
+		}
+		if optVersion > 0 && optOutput != outputTable {
+			fail("--version only supports the table output.")
+		}
+		if optOutput == outputJSON {
+			// Only the JSON document is printed
+			optQuiet = true
+		}
+		if optStdin {
+			deleteFromStdin(os.Stdin)
+			return
+		}
+		applyDefaultType(args)
 		if optRaw == "" && optImg == "" {
 			fail("Missing -i or -r option.")
 		}
-		var prepend string
-		if optPath != "" {
-			prepend = ensureTrailingSlash(optPath)
-		} else if settings.PrependPath != "" {
-			prepend = ensureTrailingSlash(settings.PrependPath)
-		}
-		if optRaw != "" {
-			publicID := composePublicID(optRaw)
-			printPublicID(publicID)
-			step(fmt.Sprintf("Deleting raw file %s", optRaw))
-			if err := service.Delete(optRaw, prepend, cloudinary.RawType); err != nil {
-				perror(err)
-			}
-		} else {
+		connectDatabase(false)
+		if optVersion > 0 {
+			rtype := cloudinary.ImageType
 			publicID := composePublicID(optImg)
+			if optRaw != "" {
+				rtype = cloudinary.RawType
+				publicID = composePublicID(optRaw)
+			}
 			printPublicID(publicID)
-			step(fmt.Sprintf("Deleting image %s", optImg))
-			if err := service.Delete(optImg, prepend, cloudinary.ImageType); err != nil {
+			step(fmt.Sprintf("Deleting version %d", optVersion))
+			if optSimulate {
+				return
+			}
+			if err := service.DeleteVersion(publicID, optVersion, rtype); err != nil {
 				perror(err)
 			}
+			return
 		}
+		rtype, name := cloudinary.ImageType, optImg
+		caption := "Deleting image %s"
+		if optRaw != "" {
+			rtype, name = cloudinary.RawType, optRaw
+			caption = "Deleting raw file %s"
+		}
+		publicID := composePublicID(name)
+		printPublicID(publicID)
+		step(fmt.Sprintf(caption, name))
+		deletePublicID(publicID, rtype)
 	},
 }
 
+// deletePublicID deletes the resource with the composed publicID, as
+// printed, and reports the result.
+func deletePublicID(publicID string, rtype cloudinary.ResourceType) {
+	res, err := service.Delete(publicID, "", rtype, optInvalidate)
+	if err != nil {
+		perror(err)
+	}
+	if optOutput == outputJSON {
+		if err := writeDeleteResult(out, res); err != nil {
+			perror(err)
+		}
+	} else {
+		fmt.Fprintln(out, res.Status())
+	}
+	if len(res.Deleted) > 0 {
+		verifyDeleted(publicID, rtype)
+	}
+}
+
+var optInvalidate bool
+var optVersion int
+var optVerify bool
+var optStdin bool
+var optOlderThan, optExpirePrefix string
+
 func init() {
 	RootCmd.AddCommand(rmCmd)
+	rmCmd.Flags().IntVar(&optVersion, "version", 0, "only delete this backed up version")
+	rmCmd.Flags().BoolVar(&optVerify, "verify", false, "check that the resource is no longer listed after deletion")
+	rmCmd.Flags().BoolVar(&optInvalidate, "invalidate", false, "purge cached copies from the CDN")
+	rmCmd.Flags().StringVarP(&optOutput, "output", "o", outputTable, "output format: table or json, listing deleted, not found and protected public ids (not with --version)")
+	rmCmd.Flags().StringVar(&optOlderThan, "older-than", "", "delete all files uploaded before this age, e.g. 30d or 12h")
+	rmCmd.Flags().StringVar(&optExpirePrefix, "prefix", "", "with --older-than, only delete files whose public id starts with this prefix, e.g. temp/")
+	rmCmd.Flags().BoolVar(&optStdin, "stdin", false, "delete the public ids read from standard input, one per line")
+	rmCmd.Flags().StringVar(&optType, "type", "image", "with --older-than or --stdin, resource type: image, raw or video")
+	rmCmd.Flags().BoolVar(&optYes, "yes", false, "with --older-than or --stdin, confirm the deletion")
+}
+
+// deleteOlderThan deletes the files uploaded before --older-than.
+func deleteOlderThan() {
+	if optImg != "" || optRaw != "" || optVersion > 0 {
+		fail("--older-than can't be used with -i, -r or --version.")
+	}
+	if optOutput != outputTable {
+		fail("--older-than only supports the table output.")
+	}
+	age, err := parseAge(optOlderThan)
+	if err != nil {
+		fail(err.Error())
+	}
+	rtype, err := parseResourceType(optType)
+	if err != nil {
+		perror(err)
+	}
+	if !optYes && !optSimulate {
+		fail("--older-than deletes files in bulk: confirm with --yes, or use --simulate to count them.")
+	}
+	connectDatabase(false)
+	scope := optType + " files"
+	if optExpirePrefix != "" {
+		scope += " below " + optExpirePrefix
+	}
+	step(fmt.Sprintf("Deleting %s older than %s", scope, optOlderThan))
+	n, err := service.DeleteOlderThan(optExpirePrefix, age, rtype)
+	if optSimulate {
+		fmt.Fprintf(out, "%d files would be deleted\n", n)
+	} else {
+		fmt.Fprintf(out, "%d files deleted\n", n)
+	}
+	if err != nil {
+		perror(err)
+	}
+}
+
+// deleteFromStdin deletes the public ids read from r, one per line.
+func deleteFromStdin(r io.Reader) {
+	if optImg != "" || optRaw != "" || optVersion > 0 {
+		fail("--stdin can't be used with -i, -r or --version.")
+	}
+	rtype, err := parseResourceType(optType)
+	if err != nil {
+		perror(err)
+	}
+	if !optYes && !optSimulate {
+		fail("--stdin deletes files in bulk: confirm with --yes, or use --simulate to count them.")
+	}
+	ids := make([]string, 0)
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		if id := strings.TrimSpace(sc.Text()); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		perror(err)
+	}
+	connectDatabase(false)
+	step(fmt.Sprintf("Deleting %d %s files", len(ids), optType))
+	res, err := service.DeleteMany(ids, rtype)
+	if optOutput == outputJSON {
+		if err := writeDeleteResult(out, res); err != nil {
+			perror(err)
+		}
+	} else {
+		verb := "deleted"
+		if optSimulate {
+			verb = "would be deleted"
+		}
+		fmt.Fprintf(out, "%d files %s, %d not found, %d kept\n", len(res.Deleted), verb, len(res.NotFound), len(res.Protected))
+	}
+	if err != nil {
+		perror(err)
+	}
+}
+
+// parseAge parses an age given as a number of days, e.g. 30d, or as a
+// duration, e.g. 12h.
+func parseAge(s string) (time.Duration, error) {
+	if strings.HasSuffix(s, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(s, "d"))
+		if err != nil || days <= 0 {
+			return 0, fmt.Errorf("invalid age %q, expect e.g. 30d or 12h", s)
+		}
+		return time.Duration(days) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid age %q, expect e.g. 30d or 12h", s)
+	}
+	return d, nil
+}
+
+// writeDeleteResult writes res as an indented JSON document.
+func writeDeleteResult(w io.Writer, res *cloudinary.DeleteResult) error {
+	b, err := json.MarshalIndent(res, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s\n", b)
+	return err
+}
+
+// verifyDeleted makes sure the resource is gone, if --verify is set.
+func verifyDeleted(publicID string, rtype cloudinary.ResourceType) {
+	if !optVerify || optSimulate || service.Protected(publicID) {
+		return
+	}
+	if settings.LowercaseIDs {
+		publicID = strings.ToLower(publicID)
+	}
+	step("Verifying deletion")
+	if err := service.VerifyDeleted(publicID, rtype); err != nil {
+		perror(err)
+	}
 }
@@ -0,0 +1,111 @@
+// Copyright © 2017 Jimmy Song
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	cloudinary "github.com/rootsongjc/cloudinary-go"
+)
+
+func TestParseAge(t *testing.T) {
+	for s, exp := range map[string]time.Duration{
+		"30d":   30 * 24 * time.Hour,
+		"1d":    24 * time.Hour,
+		"12h":   12 * time.Hour,
+		"90m":   90 * time.Minute,
+		"1h30m": 90 * time.Minute,
+	} {
+		d, err := parseAge(s)
+		if err != nil {
+			t.Errorf("%s: %s", s, err)
+		} else if d != exp {
+			t.Errorf("%s: expect %s, got %s", s, exp, d)
+		}
+	}
+	for _, s := range []string{"", "d", "0d", "-3d", "1.5d", "30", "0h", "-12h", "soon"} {
+		if _, err := parseAge(s); err == nil {
+			t.Errorf("%q should be rejected", s)
+		}
+	}
+}
+
+func TestDeleteFromStdin(t *testing.T) {
+	defer func(s *cloudinary.Service) {
+		service = s
+		optSimulate, optOutput, optType = false, outputTable, "image"
+	}(service)
+	var err error
+	if service, err = cloudinary.NewService("cloudname", "key", "secret"); err != nil {
+		t.Fatal(err)
+	}
+	service.Simulate(true)
+	service.KeepFiles("keep$")
+	optSimulate, optOutput, optType = true, outputJSON, "raw"
+
+	got := captureOutput(func() {
+		deleteFromStdin(strings.NewReader("css/site.css\n\n  js/app.js \nkeep\n"))
+	})
+	res := new(cloudinary.DeleteResult)
+	if err := json.Unmarshal([]byte(got), res); err != nil {
+		t.Fatal(err)
+	}
+	if exp := []string{"css/site.css", "js/app.js"}; !reflect.DeepEqual(res.Deleted, exp) {
+		t.Errorf("expect %v to be deleted, got %v", exp, res.Deleted)
+	}
+	if !reflect.DeepEqual(res.Protected, []string{"keep"}) {
+		t.Errorf("expect keep to be protected, got %v", res.Protected)
+	}
+}
+
+func TestDeletePublicID(t *testing.T) {
+	var deleted []string
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		deleted = append(deleted, r.URL.Path+" "+r.PostForm.Get("public_id"))
+		fmt.Fprint(w, `{"result":"ok"}`)
+	}))
+	defer ts.Close()
+	defer func(s *cloudinary.Service, path, raw, prepend string) {
+		service, optPath, optRaw, settings.PrependPath = s, path, raw, prepend
+	}(service, optPath, optRaw, settings.PrependPath)
+
+	var err error
+	if service, err = cloudinary.NewService("cloudname", "key", "secret"); err != nil {
+		t.Fatal(err)
+	}
+	service.SetHTTPClient(ts.Client())
+	if err := service.SetAPIHost(ts.Listener.Addr().String()); err != nil {
+		t.Fatal(err)
+	}
+	settings.PrependPath = ""
+
+	// The id sent is the one printed
+	optPath, optRaw = "foo/", "/bar.js"
+	captureOutput(func() { deletePublicID(composePublicID(optRaw), cloudinary.RawType) })
+	optRaw = ""
+	captureOutput(func() { deletePublicID(composePublicID("/logo.png"), cloudinary.ImageType) })
+	exp := []string{"/v1_1/cloudname/raw/destroy/ foo/bar.js", "/v1_1/cloudname/image/destroy/ foo/logo"}
+	if !reflect.DeepEqual(deleted, exp) {
+		t.Errorf("expect %q, got %q", exp, deleted)
+	}
+}
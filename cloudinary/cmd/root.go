@@ -18,8 +18,10 @@ import (
 	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"net/url"
 	"os"
+	"path/filepath"
 	"regexp"
 	"strings"
 
@@ -29,24 +31,54 @@ import (
 )
 
 var cfgFile string
+var cfgDir string
+var optEnv string
+var optEnvFile string
 var optVerbose bool
+var optTrace bool
 var optSimulate bool
+var optReadOnly bool
+var optQuiet bool
+var optFailFast bool
+var optLowercaseIDs bool
+var optNoDefaultTransformation bool
+var optRetries int
 var optPath string
 var optImg string
 var optRaw string
+var optOut string
 var service *cloudinary.Service
 var settings = &Config{}
 
+// out receives the primary output of commands, such as listings or
+// URLs: standard output, or the file set with --out. Steps and errors
+// are not part of it.
+var out io.Writer = os.Stdout
+
 // RootCmd represents the base command when called without any subcommands
 var RootCmd = &cobra.Command{
 	Use:   "cloudinary",
 	Short: "A CLI tool to upload static assets to the Cloudinary service.",
+	// Commands which don't need a configured service (e.g. init)
+	// override this hook.
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		if cmd.Name() == cobra.ShellCompRequestCmd || cmd.Name() == cobra.ShellCompNoDescRequestCmd {
+			// The service is only configured to complete public
+			// ids, see completePublicIDs
+			return
+		}
+		initConfig()
+	},
 }
 
 // Execute adds all child commands to the root command sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
-	if err := RootCmd.Execute(); err != nil {
+	err := RootCmd.Execute()
+	if f, ok := out.(*os.File); ok && f != os.Stdout {
+		f.Close()
+	}
+	if err != nil {
 		fmt.Println(err)
 		os.Exit(-1)
 	}
@@ -54,57 +86,150 @@ func Execute() {
 
 func init() {
 	RootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.cloudinary.toml)")
+	RootCmd.PersistentFlags().StringVar(&cfgDir, "config-dir", "", "directory of per-environment config files (used with --env)")
+	RootCmd.PersistentFlags().StringVar(&optEnv, "env", "", "environment name, loads <config-dir>/<env>.toml")
+	RootCmd.PersistentFlags().StringVar(&optEnvFile, "env-file", "", "file of KEY=VALUE environment variables, used for ${VARNAME} substitutions")
 	RootCmd.PersistentFlags().StringVarP(&optPath, "path", "p", "", "flle prepend path")
 	RootCmd.PersistentFlags().StringVarP(&optImg, "image", "i", "", "image filename or public id")
 	RootCmd.PersistentFlags().StringVarP(&optRaw, "raw", "r", "", "raw filename or public id")
-	optSimulate = *RootCmd.PersistentFlags().BoolP("simulate", "s", false, "simulate, do nothing (dry run)")
-	optVerbose = *RootCmd.PersistentFlags().BoolP("verbose", "v", false, "verbose output")
-	cobra.OnInitialize(initConfig)
+	RootCmd.RegisterFlagCompletionFunc("image", completePublicIDs(cloudinary.ImageType))
+	RootCmd.RegisterFlagCompletionFunc("raw", completePublicIDs(cloudinary.RawType))
+	RootCmd.PersistentFlags().BoolVarP(&optSimulate, "simulate", "s", false, "simulate, do nothing (dry run)")
+	RootCmd.PersistentFlags().BoolVar(&optReadOnly, "read-only", false, "refuse any change to the account (uploads, deletions, renames, updates), even with --simulate")
+	RootCmd.PersistentFlags().BoolVarP(&optVerbose, "verbose", "v", false, "verbose output")
+	RootCmd.PersistentFlags().BoolVar(&optTrace, "trace", false, "dump the HTTP requests and responses to stderr, credentials redacted")
+	RootCmd.PersistentFlags().BoolVarP(&optQuiet, "quiet", "q", false, "quiet output, only errors are reported")
+	RootCmd.PersistentFlags().BoolVar(&optLowercaseIDs, "lowercase-ids", false, "lowercase public ids generated from file names")
+	RootCmd.PersistentFlags().BoolVar(&optNoDefaultTransformation, "no-default-transformation", false, "don't prepend the configured default transformation to delivery URLs")
+	RootCmd.PersistentFlags().IntVar(&optRetries, "retries", 0, "number of retries of failed uploads")
+	RootCmd.PersistentFlags().BoolVar(&optFailFast, "fail-fast", false, "stop batch operations at the first error")
+	RootCmd.PersistentFlags().StringVar(&optOut, "out", "", "write the command output (listings, JSON, URLs) to this file instead of standard output")
 }
 
 // initConfig reads in config file and ENV variables if set.
 func initConfig() {
-	if cfgFile != "" { // enable ability to specify config file via flag
-		viper.SetConfigFile(cfgFile)
+	if optOut != "" {
+		f, err := os.Create(optOut)
+		if err != nil {
+			fail(err.Error())
+		}
+		out = f
 	}
-
-	viper.SetConfigName(".cloudinary") // name of config file (without extension)
-	viper.AddConfigPath("$HOME")       // adding home directory as first search path
-	viper.AutomaticEnv()               // read in environment variables that match
+	if optEnvFile != "" {
+		if err := loadEnvFile(optEnvFile); err != nil {
+			fail(err.Error())
+		}
+	}
+	file, err := envConfigFile(cfgFile, cfgDir, optEnv)
+	if err != nil {
+		fail(err.Error())
+	}
+	if file != "" { // enable ability to specify config file via flag
+		viper.SetConfigFile(file)
+	} else {
+		viper.SetConfigName(".cloudinary") // name of config file (without extension)
+		viper.AddConfigPath("$HOME")       // adding home directory as first search path
+	}
+	viper.AutomaticEnv() // read in environment variables that match
 
 	// If a config file is found, read it in.
 	if err := viper.ReadInConfig(); err == nil {
-		fmt.Println("Using config file:", viper.ConfigFileUsed())
+		info("Using config file:", viper.ConfigFileUsed())
 	}
-	var err error
 	settings, err := LoadConfig()
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "%s: %s\n", flag.Arg(1), err.Error())
 		os.Exit(1)
 	}
 	service, err = cloudinary.Dial(settings.CloudinaryURI.String())
+	if err != nil {
+		fail(err.Error())
+	}
 	service.Verbose(optVerbose)
+	if optTrace {
+		service.SetTrace(os.Stderr)
+	}
 	service.Simulate(optSimulate)
+	service.SetReadOnly(settings.ReadOnly)
+	service.SetFailFast(optFailFast)
+	service.LowercaseIDs(settings.LowercaseIDs)
+	service.SetUploadRetries(optRetries)
+	service.SetIdempotentUploads(optRetries > 0)
 	service.KeepFiles(settings.KeepFilesPattern)
-	if settings.MongoURI != nil {
-		if err := service.UseDatabase(settings.MongoURI.String()); err != nil {
-			fmt.Fprintf(os.Stderr, "Error connecting to mongoDB: %s\n", err.Error())
-			os.Exit(1)
+	if settings.APIHost != "" {
+		if err := service.SetAPIHost(settings.APIHost); err != nil {
+			fail(err.Error())
+		}
+	}
+	if settings.SignatureAlgorithm != "" {
+		if err := service.SetSignatureAlgorithm(settings.SignatureAlgorithm); err != nil {
+			fail(err.Error())
 		}
 	}
+	if err := service.SetSecureDistribution(settings.SecureDistribution); err != nil {
+		fail(err.Error())
+	}
+	service.SetPrivateCDN(settings.PrivateCDN)
+	service.SetLongURLSignature(settings.LongURLSignature)
+	if err := service.SetDefaultTransformation(settings.DefaultTransformation); err != nil {
+		fail(err.Error())
+	}
+	if optOffline {
+		service.SetOffline()
+	}
 
 	if err != nil {
 		fail(err.Error())
 	}
 
 	if optSimulate {
-		fmt.Println("*** DRY RUN MODE ***")
+		info("*** DRY RUN MODE ***")
 	}
 	if len(settings.PrependPath) > 0 {
-		fmt.Println("Default remote prepend path set to: ", settings.PrependPath)
+		info("Default remote prepend path set to: ", settings.PrependPath)
 	} else {
-		fmt.Println("No default remote prepend path set")
+		info("No default remote prepend path set")
+	}
+}
+
+// connectDatabase connects the service to the MongoDB database tracking
+// uploads, if one is set. It is called by the commands using it only, so
+// that an unreachable database doesn't prevent listing files. Commands
+// syncing uploads require it; others warn and go on without tracking.
+func connectDatabase(required bool) {
+	if optOffline || settings.MongoURI == nil {
+		return
+	}
+	if err := service.UseDatabase(settings.MongoURI.String()); err != nil {
+		if required {
+			fail(fmt.Sprintf("connecting to mongoDB: %s", err.Error()))
+		}
+		fmt.Fprintf(os.Stderr, "Warning: can't connect to mongoDB (upload sync disabled): %s\n", err.Error())
+	}
+}
+
+// envConfigFile returns the config file to load. An explicit config
+// file always wins. Otherwise, if an environment name is given, the
+// <dir>/<env>.toml file is used; dir defaults to the current directory.
+// An empty string means the default $HOME/.cloudinary.toml lookup applies.
+func envConfigFile(file, dir, env string) (string, error) {
+	if file != "" {
+		return file, nil
+	}
+	if env == "" {
+		if dir != "" {
+			return "", errors.New("--config-dir requires --env")
+		}
+		return "", nil
 	}
+	path := filepath.Join(dir, env+".toml")
+	if _, err := os.Stat(path); err != nil {
+		if os.IsNotExist(err) {
+			return "", fmt.Errorf("no config file for env %q: %s not found", env, path)
+		}
+		return "", err
+	}
+	return path, nil
 }
 
 // Config for cloudinary
@@ -114,6 +239,36 @@ type Config struct {
 	// Url to a MongoDB instance, used to track files and upload
 	// only changed. Optional.
 	MongoURI *url.URL
+	// Host serving the Cloudinary API, for regional or custom
+	// endpoints. Optional.
+	APIHost string
+	// Algorithm used to sign requests, sha1 (default) or sha256.
+	SignatureAlgorithm string
+	// Signed delivery URLs carry 32 character signatures, which must be
+	// enabled for the account. Optional.
+	LongURLSignature bool
+	// Host delivering files, e.g. a custom CNAME, instead of
+	// res.cloudinary.com. Optional.
+	SecureDistribution string
+	// Files are delivered by a private CDN, whose URLs don't hold the
+	// cloud name. Optional.
+	PrivateCDN bool
+	// Transformation prepended to all delivery URLs, e.g.
+	// "f_auto,q_auto". Disabled with --no-default-transformation.
+	// Optional.
+	DefaultTransformation string
+	// Resource type of the file given as argument to commands like put
+	// or rm when neither -i nor -r is used. Optional.
+	DefaultType string
+	// File keeping track of the last ls --since-last run. Optional,
+	// defaults to $HOME/.cloudinary.state.json.
+	StateFile string
+	// Lowercase public ids, so that they don't depend on the case of
+	// local file names. Also set with --lowercase-ids.
+	LowercaseIDs bool
+	// Refuse any change to the account, e.g. for audits. Also set
+	// with --read-only.
+	ReadOnly bool
 	// Regexp pattern to prevent remote file deletion.
 	KeepFilesPattern string
 	// An optional remote prepend path, used to generate a unique
@@ -142,6 +297,10 @@ func LoadConfig() (*Config, error) {
 	if uri = viper.GetString("cloudinary.uri"); err != nil {
 		return nil, err
 	}
+	// Substitute before parsing: ${VARNAME} is not valid in credentials
+	if uri, err = replaceEnvVars(uri); err != nil {
+		return nil, err
+	}
 	if cURI, err = url.Parse(uri); err != nil {
 		return nil, errors.New(fmt.Sprint("cloudinary URI: ", err.Error()))
 	}
@@ -151,6 +310,26 @@ func LoadConfig() (*Config, error) {
 	prepend := viper.GetString("cloudinary.prepend")
 	settings.PrependPath = cloudinary.EnsureTrailingSlash(prepend)
 	settings.ProdTag = viper.GetString("global.prodtag")
+	settings.ReadOnly = optReadOnly || viper.GetBool("global.read_only")
+
+	// API host (optional)
+	settings.APIHost = viper.GetString("cloudinary.api_host")
+	settings.SignatureAlgorithm = viper.GetString("cloudinary.signature_algorithm")
+	settings.LongURLSignature = viper.GetBool("cloudinary.long_url_signature")
+	settings.SecureDistribution = viper.GetString("cloudinary.secure_distribution")
+	settings.PrivateCDN = viper.GetBool("cloudinary.private_cdn")
+	settings.DefaultTransformation = viper.GetString("cloudinary.default_transformation")
+	if optNoDefaultTransformation {
+		settings.DefaultTransformation = ""
+	}
+	settings.StateFile = viper.GetString("cloudinary.state_file")
+	settings.DefaultType = strings.ToLower(viper.GetString("cloudinary.default_type"))
+	if settings.DefaultType != "" {
+		if _, err := parseResourceType(settings.DefaultType); err != nil {
+			return nil, errors.New(fmt.Sprint("default type: ", err.Error()))
+		}
+	}
+	settings.LowercaseIDs = optLowercaseIDs || viper.GetBool("cloudinary.lowercase_ids")
 
 	// Keep files regexp? (optional)
 	var pattern string
@@ -167,7 +346,7 @@ func LoadConfig() (*Config, error) {
 			return nil, errors.New(fmt.Sprint("mongoDB URI: ", err.Error()))
 		}
 		settings.MongoURI = mURI
-	} else {
+	} else if !optQuiet {
 		fmt.Fprintf(os.Stderr, "Warning: database not set (upload sync disabled)\n")
 	}
 	// Looks for env variables, perform substitutions if needed
@@ -218,7 +397,7 @@ func (c *Config) handleEnvVars() error {
 // replaceEnvVars replaces all ${VARNAME} with their value
 // using os.Getenv().
 func replaceEnvVars(src string) (string, error) {
-	r, err := regexp.Compile(`\${([A-Z_]+)}`)
+	r, err := regexp.Compile(`\${([A-Za-z_][A-Za-z0-9_]*)}`)
 	if err != nil {
 		return "", err
 	}
@@ -259,12 +438,60 @@ func perror(err error) {
 	os.Exit(1)
 }
 
+// info prints informational output, unless quiet mode is on.
+func info(a ...interface{}) {
+	if optQuiet {
+		return
+	}
+	fmt.Println(a...)
+}
+
 func step(caption string) {
-	fmt.Printf("==> %s\n", caption)
+	info(fmt.Sprintf("==> %s", caption))
 }
 
 func printPublicID(publicID string) {
-	fmt.Println("==> PublicID:", publicID)
+	info("==> PublicID:", publicID)
+}
+
+// parseResourceType returns the resource type named name.
+func parseResourceType(name string) (cloudinary.ResourceType, error) {
+	switch strings.ToLower(name) {
+	case "image":
+		return cloudinary.ImageType, nil
+	case "raw":
+		return cloudinary.RawType, nil
+	case "video":
+		return cloudinary.VideoType, nil
+	case "pdf":
+		return cloudinary.PdfType, nil
+	case "auto":
+		return cloudinary.AutoType, nil
+	}
+	return cloudinary.ImageType, fmt.Errorf("unknown resource type %q (image, raw, video, pdf or auto)", name)
+}
+
+// applyDefaultType uses the single file name argument as -r or -i,
+// depending on the default type setting, if neither option is given.
+func applyDefaultType(args []string) {
+	if optImg != "" || optRaw != "" || len(args) != 1 || settings.DefaultType == "" {
+		return
+	}
+	if settings.DefaultType == "raw" {
+		optRaw = args[0]
+	} else {
+		optImg = args[0]
+	}
+}
+
+// defaultImageType returns the resource type of -i files, ImageType
+// unless another non-raw default type is set.
+func defaultImageType() cloudinary.ResourceType {
+	if settings.DefaultType == "" || settings.DefaultType == "raw" {
+		return cloudinary.ImageType
+	}
+	rtype, _ := parseResourceType(settings.DefaultType)
+	return rtype
 }
 
 func composePublicID(opt string) string {
@@ -274,8 +501,27 @@ func composePublicID(opt string) string {
 	} else if settings.PrependPath != "" {
 		prepend = ensureTrailingSlash(settings.PrependPath)
 	}
+	var id string
 	if optRaw != "" {
-		return prepend + opt
+		id = normalizePublicID(prepend + opt)
+	} else {
+		id = normalizePublicID(cloudinary.CleanExtensionNameWithPrepend(opt, prepend))
+	}
+	if settings.LowercaseIDs {
+		id = strings.ToLower(id)
+	}
+	return id
+}
+
+// normalizePublicID collapses repeated slashes and removes leading and
+// trailing slashes, so that joined paths don't create empty folders.
+func normalizePublicID(id string) string {
+	parts := strings.Split(id, "/")
+	kept := parts[:0]
+	for _, p := range parts {
+		if p != "" {
+			kept = append(kept, p)
+		}
 	}
-	return cloudinary.CleanExtensionNameWithPrepend(opt, prepend)
+	return strings.Join(kept, "/")
 }
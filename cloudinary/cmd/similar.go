@@ -0,0 +1,56 @@
+// Copyright © 2017 Jimmy Song
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var optDistance int
+
+// similarCmd represents the similar command
+var similarCmd = &cobra.Command{
+	Use:   "similar",
+	Short: "List images similar to an image",
+	Run: func(cmd *cobra.Command, args []string) {
+		if optImg == "" {
+			fail("Missing -i option.")
+		}
+		publicID := composePublicID(optImg)
+		printPublicID(publicID)
+		step("Looking for similar images")
+		res, err := service.FindSimilar(publicID, optDistance)
+		if err != nil {
+			perror(err)
+		}
+		if len(res) == 0 {
+			info("No similar image found.")
+			return
+		}
+		fmt.Fprintf(out, "%-30s %-16s %s\n", "public_id", "phash", "Url")
+		fmt.Fprintln(out, strings.Repeat("-", 70))
+		for _, r := range res {
+			fmt.Fprintf(out, "%-30s %-16s %s\n", r.PublicId, r.Phash, r.Url)
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(similarCmd)
+	similarCmd.Flags().IntVar(&optDistance, "distance", 10, "maximum perceptual hash distance (in bits)")
+}
@@ -0,0 +1,113 @@
+// Copyright © 2017 Jimmy Song
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	cloudinary "github.com/rootsongjc/cloudinary-go"
+)
+
+// Default state file name, in the home directory.
+const defaultStateFile = ".cloudinary.state.json"
+
+// lsState is saved between ls --since-last runs, along with the
+// asynchronous uploads to follow up with jobs ls.
+type lsState struct {
+	// Creation date of the most recent resource listed so far
+	LastCreatedAt time.Time `json:"last_created_at"`
+	// Asynchronous jobs not seen complete yet
+	Jobs []*asyncJob `json:"jobs,omitempty"`
+}
+
+// stateFile returns the path of the state file, from the config file
+// or in the home directory by default.
+func stateFile() (string, error) {
+	if settings.StateFile != "" {
+		return settings.StateFile, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, defaultStateFile), nil
+}
+
+// readState reads the state file at path. A missing file is not an
+// error: an empty state is returned.
+func readState(path string) (*lsState, error) {
+	st := new(lsState)
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return st, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, st); err != nil {
+		return nil, fmt.Errorf("state file %s: %s", path, err.Error())
+	}
+	return st, nil
+}
+
+// writeState atomically replaces the state file at path.
+func writeState(path string, st *lsState) error {
+	data, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// sinceExpression returns the search expression matching the resources
+// uploaded after since, along with the tag and format filters, if any.
+func sinceExpression(since time.Time, tag, withoutTag, format string) string {
+	terms := make([]string, 0, 4)
+	if !since.IsZero() {
+		terms = append(terms, fmt.Sprintf("uploaded_at>%q", since.UTC().Format(time.RFC3339)))
+	}
+	if tag != "" {
+		terms = append(terms, fmt.Sprintf("tags=%q", tag))
+	}
+	if withoutTag != "" {
+		terms = append(terms, fmt.Sprintf("-tags=%q", withoutTag))
+	}
+	if format != "" {
+		terms = append(terms, fmt.Sprintf("format=%q", cloudinary.NormalizeFormat(format)))
+	}
+	return strings.Join(terms, " AND ")
+}
+
+// latestCreatedAt returns the most recent creation date of res, or
+// since if none is more recent.
+func latestCreatedAt(res []*cloudinary.Resource, since time.Time) time.Time {
+	latest := since
+	for _, r := range res {
+		if r.CreatedAt.After(latest) {
+			latest = r.CreatedAt
+		}
+	}
+	return latest
+}
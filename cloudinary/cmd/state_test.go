@@ -0,0 +1,96 @@
+// Copyright © 2017 Jimmy Song
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	cloudinary "github.com/rootsongjc/cloudinary-go"
+)
+
+func TestReadWriteState(t *testing.T) {
+	dir, err := ioutil.TempDir("", "state")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "state.json")
+
+	st, err := readState(path)
+	if err != nil {
+		t.Fatalf("a missing state file should not be an error: %v", err)
+	}
+	if !st.LastCreatedAt.IsZero() {
+		t.Errorf("expect an empty state, got %v", st.LastCreatedAt)
+	}
+
+	st.LastCreatedAt = time.Date(2017, 10, 29, 6, 49, 5, 0, time.UTC)
+	if err := writeState(path, st); err != nil {
+		t.Fatal(err)
+	}
+	got, err := readState(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.LastCreatedAt.Equal(st.LastCreatedAt) {
+		t.Errorf("expect %v, got %v", st.LastCreatedAt, got.LastCreatedAt)
+	}
+
+	ioutil.WriteFile(path, []byte("{"), 0600)
+	if _, err := readState(path); err == nil {
+		t.Error("a corrupted state file should be reported")
+	}
+}
+
+func TestSinceExpression(t *testing.T) {
+	since := time.Date(2017, 10, 29, 8, 49, 5, 0, time.FixedZone("CEST", 2*3600))
+	tests := []struct {
+		since        time.Time
+		tag, without string
+		format       string
+		expect       string
+	}{
+		{time.Time{}, "", "", "", ""},
+		{since, "", "", "", `uploaded_at>"2017-10-29T06:49:05Z"`},
+		{since, "brand", "draft", "", `uploaded_at>"2017-10-29T06:49:05Z" AND tags="brand" AND -tags="draft"`},
+		{time.Time{}, "brand", "", "", `tags="brand"`},
+		{time.Time{}, "", "", ".SVG", `format="svg"`},
+		{since, "brand", "", "pdf", `uploaded_at>"2017-10-29T06:49:05Z" AND tags="brand" AND format="pdf"`},
+	}
+	for _, tt := range tests {
+		if e := sinceExpression(tt.since, tt.tag, tt.without, tt.format); e != tt.expect {
+			t.Errorf("expect %s, got %s", tt.expect, e)
+		}
+	}
+}
+
+func TestLatestCreatedAt(t *testing.T) {
+	since := time.Date(2017, 10, 29, 0, 0, 0, 0, time.UTC)
+	res := []*cloudinary.Resource{
+		{PublicId: "a", CreatedAt: since.Add(time.Hour)},
+		{PublicId: "b", CreatedAt: since.Add(2 * time.Hour)},
+		{PublicId: "c", CreatedAt: since.Add(-time.Hour)},
+	}
+	if l := latestCreatedAt(res, since); !l.Equal(since.Add(2 * time.Hour)) {
+		t.Errorf("wrong latest date %v", l)
+	}
+	if l := latestCreatedAt(nil, since); !l.Equal(since) {
+		t.Errorf("expect %v without resources, got %v", since, l)
+	}
+}
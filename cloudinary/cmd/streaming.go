@@ -0,0 +1,82 @@
+// Copyright © 2017 Jimmy Song
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var optDisplayName string
+
+// streamingCmd represents the streaming-profiles command
+var streamingCmd = &cobra.Command{
+	Use:   "streaming-profiles",
+	Short: "Manage video streaming profiles",
+}
+
+var streamingLsCmd = &cobra.Command{
+	Use:   "ls",
+	Short: "List streaming profiles",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		profiles, err := service.StreamingProfiles()
+		if err != nil {
+			perror(err)
+		}
+		if len(profiles) == 0 {
+			info("No streaming profile found.")
+			return
+		}
+		for _, p := range profiles {
+			kind := "custom"
+			if p.Predefined {
+				kind = "predefined"
+			}
+			fmt.Fprintf(out, "%-20s %-30s %s\n", p.Name, p.DisplayName, kind)
+		}
+	},
+}
+
+var streamingCreateCmd = &cobra.Command{
+	Use:   "create <name> <transformation>...",
+	Short: "Create a streaming profile, one representation per transformation",
+	Args:  cobra.MinimumNArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		step(fmt.Sprintf("Creating streaming profile %s", args[0]))
+		if err := service.CreateStreamingProfile(args[0], optDisplayName, args[1:]); err != nil {
+			perror(err)
+		}
+	},
+}
+
+var streamingRmCmd = &cobra.Command{
+	Use:   "rm <name>",
+	Short: "Remove a streaming profile, or restore a predefined one",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		step(fmt.Sprintf("Removing streaming profile %s", args[0]))
+		if err := service.DeleteStreamingProfile(args[0]); err != nil {
+			perror(err)
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(streamingCmd)
+	streamingCmd.AddCommand(streamingLsCmd, streamingCreateCmd, streamingRmCmd)
+	streamingCreateCmd.Flags().StringVar(&optDisplayName, "display-name", "", "name displayed in the console")
+}
@@ -0,0 +1,113 @@
+// Copyright © 2017 Jimmy Song
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	cloudinary "github.com/rootsongjc/cloudinary-go"
+	"github.com/spf13/cobra"
+)
+
+// updateCmd represents the update command
+var updateCmd = &cobra.Command{
+	Use:   "update",
+	Short: "Update tags, context and access mode of a file",
+	Run: func(cmd *cobra.Command, args []string) {
+		if optRaw == "" && optImg == "" {
+			fail("Missing -i or -r option.")
+		}
+		opts := cloudinary.UpdateOptions{
+			AddTags:    optAddTags,
+			RemoveTags: optRemoveTags,
+			AccessMode: optAccess,
+		}
+		if cmd.Flags().Changed("set-tags") {
+			opts.Tags = optSetTags
+		}
+		ctx, err := parseContext(optContext)
+		if err != nil {
+			fail(err.Error())
+		}
+		opts.Context = ctx
+
+		rtype := cloudinary.ImageType
+		publicID := composePublicID(optImg)
+		if optRaw != "" {
+			rtype = cloudinary.RawType
+			publicID = composePublicID(optRaw)
+		}
+		printPublicID(publicID)
+		step(fmt.Sprintf("Updating %s", publicID))
+		if optSimulate {
+			return
+		}
+		d, err := service.Update(publicID, opts, rtype)
+		if err != nil {
+			perror(err)
+		}
+		info("Tags:", strings.Join(d.Tags, ","))
+		if d.Context != nil && len(d.Context.Custom) > 0 {
+			info("Context:", formatContext(d.Context.Custom))
+		}
+		if d.AccessMode != "" {
+			info("Access:", d.AccessMode)
+		}
+	},
+}
+
+var optAddTags []string
+var optRemoveTags []string
+var optSetTags []string
+var optContext []string
+var optAccess string
+
+func init() {
+	RootCmd.AddCommand(updateCmd)
+	updateCmd.Flags().StringArrayVar(&optAddTags, "add-tag", nil, "add a tag (repeatable)")
+	updateCmd.Flags().StringArrayVar(&optRemoveTags, "remove-tag", nil, "remove a tag (repeatable)")
+	updateCmd.Flags().StringSliceVar(&optSetTags, "set-tags", nil, "replace all tags with this comma separated list")
+	updateCmd.Flags().StringArrayVar(&optContext, "set-context", nil, "set a context entry as key=value (repeatable)")
+	updateCmd.Flags().StringVar(&optAccess, "access", "", "access mode: public or authenticated")
+}
+
+// parseContext parses key=value pairs, e.g. context entries or
+// metadata fields, into a map.
+func parseContext(pairs []string) (map[string]string, error) {
+	if len(pairs) == 0 {
+		return nil, nil
+	}
+	ctx := make(map[string]string, len(pairs))
+	for _, p := range pairs {
+		kv := strings.SplitN(p, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return nil, fmt.Errorf("invalid pair %q, must be key=value", p)
+		}
+		ctx[kv[0]] = kv[1]
+	}
+	return ctx, nil
+}
+
+// formatContext formats key-value pairs as key=value, comma separated.
+func formatContext(ctx map[string]string) string {
+	pairs := make([]string, 0, len(ctx))
+	for k, v := range ctx {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ", ")
+}
@@ -0,0 +1,39 @@
+// Copyright © 2017 Jimmy Song
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseContext(t *testing.T) {
+	ctx, err := parseContext([]string{"alt=Logo", "caption=a=b", "empty="})
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := map[string]string{"alt": "Logo", "caption": "a=b", "empty": ""}
+	if !reflect.DeepEqual(ctx, exp) {
+		t.Errorf("wrong context. Expect %v, got %v", exp, ctx)
+	}
+	for _, p := range []string{"alt", "=Logo"} {
+		if _, err := parseContext([]string{p}); err == nil {
+			t.Errorf("%q should be rejected", p)
+		}
+	}
+	if s := formatContext(exp); s != "alt=Logo, caption=a=b, empty=" {
+		t.Errorf("wrong formatted context %q", s)
+	}
+}
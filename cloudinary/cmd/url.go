@@ -0,0 +1,113 @@
+// Copyright © 2017 Jimmy Song
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	cloudinary "github.com/rootsongjc/cloudinary-go"
+	"github.com/spf13/cobra"
+)
+
+var optURL cloudinary.URLOptions
+var optSign bool
+var optOffline bool
+var optIf, optThen, optElse string
+var optCrop, optWidth, optDpr string
+
+// urlCmd represents the url command
+var urlCmd = &cobra.Command{
+	Use:   "url",
+	Short: "Print the delivery URL of a file",
+	Run: func(cmd *cobra.Command, args []string) {
+		if optRaw == "" && optImg == "" {
+			fail("Missing -i or -r option.")
+		}
+		rtype := cloudinary.ImageType
+		publicID := composePublicID(optImg)
+		if optRaw != "" {
+			rtype = cloudinary.RawType
+			publicID = composePublicID(optRaw)
+		}
+		if optURL.Page < 0 {
+			fail("--page must be positive.")
+		}
+		t, err := urlTransformation()
+		if err != nil {
+			perror(err)
+		}
+		optURL.Transformation = t.String()
+		if optSign {
+			fmt.Fprintln(out, service.SignedURL(publicID, rtype, &optURL))
+		} else {
+			fmt.Fprintln(out, service.DeliveryURL(publicID, rtype, &optURL))
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(urlCmd)
+	urlCmd.Flags().IntVar(&optURL.Version, "version", 0, "pin this version of the file")
+	urlCmd.Flags().StringVar(&optURL.Transformation, "transformation", "", "transformation, e.g. w_300,h_200,c_fill")
+	urlCmd.Flags().StringVar(&optURL.Format, "format", "", "delivery format, e.g. webp")
+	urlCmd.Flags().IntVar(&optURL.Page, "page", 0, "page of a multi-page PDF or TIFF file, rasterized with --format jpg")
+	urlCmd.Flags().StringVar(&optCrop, "crop", "", "crop mode, e.g. scale or fill")
+	urlCmd.Flags().StringVar(&optWidth, "width", "", "width in pixels, or auto for responsive delivery (requires --crop)")
+	urlCmd.Flags().StringVar(&optDpr, "dpr", "", "device pixel ratio, auto or e.g. 2.0")
+	urlCmd.Flags().StringVar(&optIf, "if", "", "condition of a conditional transformation appended to --transformation, e.g. w_gt_1000")
+	urlCmd.Flags().StringVar(&optThen, "then", "", "transformation applied if the --if condition is met, e.g. c_scale,w_1000")
+	urlCmd.Flags().StringVar(&optElse, "else", "", "transformation applied if the --if condition is not met")
+	urlCmd.Flags().BoolVar(&optSign, "sign", false, "sign the URL")
+	urlCmd.Flags().BoolVar(&optOffline, "offline", false, "guarantee no network access: neither the API nor the database is contacted")
+}
+
+// urlTransformation returns the --transformation, followed by the
+// resizing set with --crop, --width and --dpr and the conditional
+// block set with --if, --then and --else, if any.
+func urlTransformation() (*cloudinary.Transformation, error) {
+	t, err := cloudinary.ParseTransformation(optURL.Transformation)
+	if err != nil {
+		return nil, fmt.Errorf("transformation: %s", err)
+	}
+	if optWidth == "auto" && optCrop == "" {
+		return nil, errors.New("--width auto requires --crop, e.g. --crop scale")
+	}
+	t.Resize(optCrop, optWidth, optDpr)
+	if optIf == "" {
+		if optThen != "" || optElse != "" {
+			return nil, errors.New("--then and --else require --if")
+		}
+	} else {
+		t.If(optIf, splitParams(optThen)...)
+		if optElse != "" {
+			t.Else(splitParams(optElse)...)
+		}
+		t.EndIf()
+	}
+	if err := t.Validate(); err != nil {
+		return nil, fmt.Errorf("transformation: %s", err)
+	}
+	return t, nil
+}
+
+// splitParams splits comma-separated transformation parameters.
+func splitParams(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
+}
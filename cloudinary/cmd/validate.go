@@ -0,0 +1,41 @@
+// Copyright © 2017 Jimmy Song
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	cloudinary "github.com/rootsongjc/cloudinary-go"
+	"github.com/spf13/cobra"
+)
+
+// validateCmd represents the validate-transformation command
+var validateCmd = &cobra.Command{
+	Use:   "validate-transformation <transformation>",
+	Short: "Check a transformation locally, e.g. w_300,c_fill,g_face",
+	Args:  cobra.ExactArgs(1),
+	// No configured service needed
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {},
+	Run: func(cmd *cobra.Command, args []string) {
+		if _, err := cloudinary.ParseTransformation(args[0]); err != nil {
+			fail(err.Error())
+		}
+		fmt.Fprintln(out, "OK")
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(validateCmd)
+}
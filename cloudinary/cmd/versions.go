@@ -0,0 +1,74 @@
+// Copyright © 2017 Jimmy Song
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	cloudinary "github.com/rootsongjc/cloudinary-go"
+	"github.com/spf13/cobra"
+)
+
+// versionsCmd represents the versions command
+var versionsCmd = &cobra.Command{
+	Use:   "versions",
+	Short: "List the backed up versions of a file",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if optRaw == "" && optImg == "" {
+			fail("Missing -i or -r option.")
+		}
+		rtype := cloudinary.ImageType
+		publicID := composePublicID(optImg)
+		if optRaw != "" {
+			rtype = cloudinary.RawType
+			publicID = composePublicID(optRaw)
+		}
+		vs, err := service.Versions(publicID, rtype)
+		if err != nil {
+			perror(err)
+		}
+		printVersions(vs)
+	},
+}
+
+// printVersions prints backed up versions, oldest first.
+func printVersions(vs []cloudinary.VersionInfo) {
+	if len(vs) == 0 {
+		info("No backed up version found.")
+		return
+	}
+	fmt.Fprintf(out, "%-12s %-10s %-20s %s\n", "Version", "Size", "Backed up", "Status")
+	fmt.Fprintln(out, strings.Repeat("-", 60))
+	for _, v := range vs {
+		t := "-"
+		if !v.Time.IsZero() {
+			t = v.Time.Local().Format("2006-01-02 15:04:05")
+		}
+		status := "restorable"
+		switch {
+		case v.Current:
+			status = "current"
+		case !v.Restorable:
+			status = "not restorable"
+		}
+		fmt.Fprintf(out, "%-12d %-10s %-20s %s\n", v.Version, humanBytes(v.Size), t, status)
+	}
+}
+
+func init() {
+	RootCmd.AddCommand(versionsCmd)
+}
@@ -0,0 +1,193 @@
+// Copyright © 2017 Jimmy Song
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	cloudinary "github.com/rootsongjc/cloudinary-go"
+	"github.com/spf13/cobra"
+)
+
+var optDebounce time.Duration
+var optType string
+
+// watchCmd represents the watch command
+var watchCmd = &cobra.Command{
+	Use:   "watch <dir>",
+	Short: "Upload files as they change in a directory",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if optPath != "" {
+			settings.PrependPath = optPath
+		}
+		rtype, err := parseResourceType(optType)
+		if err != nil {
+			perror(err)
+		}
+		connectDatabase(true)
+		watcher, err := fsnotify.NewWatcher()
+		if err != nil {
+			perror(err)
+		}
+		defer watcher.Close()
+		if err := watchTree(watcher, args[0]); err != nil {
+			perror(err)
+		}
+
+		// Uploads are done one at a time, from this goroutine only
+		uploads := make(chan string)
+		d := newDebouncer(optDebounce, func(path string) { uploads <- path })
+		defer d.stop()
+
+		sig := make(chan os.Signal, 1)
+		signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+		step(fmt.Sprintf("Watching %s (Ctrl-C to stop)", args[0]))
+		for {
+			select {
+			case ev, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if ev.Op&(fsnotify.Create|fsnotify.Write) == 0 || isTempFile(ev.Name) {
+					continue
+				}
+				if fi, err := os.Stat(ev.Name); err == nil && fi.IsDir() {
+					if err := watchTree(watcher, ev.Name); err != nil {
+						fmt.Fprintf(os.Stderr, "Error: %s\n", err.Error())
+					}
+					continue
+				}
+				d.add(ev.Name)
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				fmt.Fprintf(os.Stderr, "Error: %s\n", err.Error())
+			case path := <-uploads:
+				watchUpload(path, rtype)
+			case <-sig:
+				m := service.Metrics()
+				step(fmt.Sprintf("Stopped: %d files uploaded (%d bytes), %d API errors", m.Uploads, m.BytesUploaded, m.APIErrors))
+				return
+			}
+		}
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(watchCmd)
+	watchCmd.Flags().DurationVar(&optDebounce, "debounce", 500*time.Millisecond, "wait for files to be left unchanged for this long before uploading")
+	watchCmd.Flags().StringVar(&optType, "type", "image", "resource type of uploaded files (image, raw, video, pdf or auto)")
+}
+
+// watchUpload uploads a file which has settled. Files which have been
+// removed in the meantime or which are protected by the keep files
+// pattern are skipped.
+func watchUpload(path string, rtype cloudinary.ResourceType) {
+	fi, err := os.Stat(path)
+	if err != nil || fi.IsDir() {
+		return
+	}
+	publicID := cloudinary.CleanExtensionNameWithPrepend(path, settings.PrependPath)
+	if service.Protected(publicID) {
+		info("Skipping protected resource:", publicID)
+		return
+	}
+	printPublicID(publicID)
+	if _, err := service.UploadWithOptions(path, nil, settings.PrependPath, false, rtype, nil); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s: %s\n", path, err.Error())
+	}
+}
+
+// watchTree watches dir and all its sub-directories.
+func watchTree(watcher *fsnotify.Watcher, dir string) error {
+	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return watcher.Add(path)
+		}
+		return nil
+	})
+}
+
+// isTempFile reports whether path looks like a file written by an
+// editor before being renamed to its final name (atomic save).
+func isTempFile(path string) bool {
+	name := filepath.Base(path)
+	switch {
+	case strings.HasPrefix(name, ".#"), strings.HasPrefix(name, ".goutputstream-"):
+		return true
+	case strings.HasSuffix(name, "~"):
+		return true
+	}
+	switch filepath.Ext(name) {
+	case ".swp", ".swx", ".tmp":
+		return true
+	}
+	return false
+}
+
+// debouncer coalesces bursts of events on a path: the flush function is
+// called once no event has been seen on the path for the delay.
+type debouncer struct {
+	delay  time.Duration
+	flush  func(path string)
+	mu     sync.Mutex
+	timers map[string]*time.Timer
+}
+
+func newDebouncer(delay time.Duration, flush func(path string)) *debouncer {
+	return &debouncer{
+		delay:  delay,
+		flush:  flush,
+		timers: make(map[string]*time.Timer),
+	}
+}
+
+// add records an event on path, postponing its flush.
+func (d *debouncer) add(path string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if t, ok := d.timers[path]; ok {
+		t.Stop()
+	}
+	d.timers[path] = time.AfterFunc(d.delay, func() {
+		d.mu.Lock()
+		delete(d.timers, path)
+		d.mu.Unlock()
+		d.flush(path)
+	})
+}
+
+// stop cancels all pending flushes.
+func (d *debouncer) stop() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for path, t := range d.timers {
+		t.Stop()
+		delete(d.timers, path)
+	}
+}
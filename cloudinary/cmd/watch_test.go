@@ -0,0 +1,72 @@
+// Copyright © 2017 Jimmy Song
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDebouncer(t *testing.T) {
+	var mu sync.Mutex
+	flushed := make(map[string]int)
+	d := newDebouncer(50*time.Millisecond, func(path string) {
+		mu.Lock()
+		flushed[path]++
+		mu.Unlock()
+	})
+	// A burst of writes on a file is coalesced
+	for i := 0; i < 5; i++ {
+		d.add("dist/app.js")
+		time.Sleep(10 * time.Millisecond)
+	}
+	d.add("dist/app.css")
+	time.Sleep(200 * time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if flushed["dist/app.js"] != 1 {
+		t.Errorf("expect a single flush of app.js, got %d", flushed["dist/app.js"])
+	}
+	if flushed["dist/app.css"] != 1 {
+		t.Errorf("expect a single flush of app.css, got %d", flushed["dist/app.css"])
+	}
+}
+
+func TestDebouncerStop(t *testing.T) {
+	flushed := make(chan string, 1)
+	d := newDebouncer(20*time.Millisecond, func(path string) { flushed <- path })
+	d.add("dist/app.js")
+	d.stop()
+	select {
+	case p := <-flushed:
+		t.Errorf("%s flushed after stop", p)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestIsTempFile(t *testing.T) {
+	for _, p := range []string{"dist/.app.js.swp", "dist/app.js~", "dist/.#app.js", "dist/app.js.tmp"} {
+		if !isTempFile(p) {
+			t.Errorf("%s should be a temporary file", p)
+		}
+	}
+	for _, p := range []string{"dist/app.js", "dist/logo.png"} {
+		if isTempFile(p) {
+			t.Errorf("%s should not be a temporary file", p)
+		}
+	}
+}
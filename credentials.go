@@ -0,0 +1,42 @@
+// Copyright 2013 Mathias Monnerville and Anthony Baillard.
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cloudinary
+
+import (
+	"errors"
+	"net/url"
+)
+
+// UpdateCredentials replaces the API key and secret used to sign and
+// authenticate requests, e.g. when secrets are rotated, keeping the
+// HTTP client, caches and database connection. Requests already being
+// built keep using the previous credentials, the next ones use the new
+// credentials.
+func (s *Service) UpdateCredentials(apiKey, apiSecret string) error {
+	if apiSecret == "" {
+		return errors.New("No API secret provided.")
+	}
+	s.credsMu.Lock()
+	defer s.credsMu.Unlock()
+	s.apiKey, s.apiSecret = apiKey, apiSecret
+	return nil
+}
+
+// credentials returns the current API key and secret.
+func (s *Service) credentials() (key, secret string) {
+	s.credsMu.RLock()
+	defer s.credsMu.RUnlock()
+	return s.apiKey, s.apiSecret
+}
+
+// adminURL returns the base URL of the admin API, authenticated with
+// the current credentials.
+func (s *Service) adminURL() *url.URL {
+	key, secret := s.credentials()
+	u := *s.adminURI
+	u.User = url.UserPassword(key, secret)
+	return &u
+}
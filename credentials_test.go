@@ -0,0 +1,89 @@
+// Copyright 2013 Mathias Monnerville and Anthony Baillard.
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+package cloudinary
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestUpdateCredentials(t *testing.T) {
+	secrets := map[string]string{"key": "secret", "key2": "secret2"}
+	var mu sync.Mutex
+	keys := make([]string, 0)
+	s, ts := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var key string
+		if r.Method == "POST" {
+			// Upload or rename, signed
+			r.ParseMultipartForm(1 << 20)
+			params := r.PostForm
+			key = params.Get("api_key")
+			signature := params.Get("signature")
+			params.Del("api_key")
+			params.Del("signature")
+			if exp := apiSignature(params, secrets[key], SignatureSHA1); signature != exp {
+				t.Errorf("%s: signature %s doesn't match the key", key, signature)
+			}
+		} else {
+			var secret string
+			key, secret, _ = r.BasicAuth()
+			if secret != secrets[key] {
+				t.Errorf("%s: wrong admin secret %s", key, secret)
+			}
+		}
+		mu.Lock()
+		keys = append(keys, key)
+		mu.Unlock()
+		fmt.Fprint(w, `{"public_id":"logo","version":1,"status":"ok"}`)
+	}))
+	defer ts.Close()
+
+	requests := func() {
+		if _, err := s.UploadWithOptions("logo.png", strings.NewReader("png"), "", false, ImageType, nil); err != nil {
+			t.Error(err)
+		}
+		if err := s.Ping(); err != nil {
+			t.Error(err)
+		}
+	}
+	requests()
+	if err := s.UpdateCredentials("key2", "secret2"); err != nil {
+		t.Fatal(err)
+	}
+	requests()
+	if k := strings.Join(keys, ","); k != "key,key,key2,key2" {
+		t.Errorf("expect the old then the new key, got %s", k)
+	}
+	if s.ApiKey() != "key2" {
+		t.Errorf("expect key2, got %s", s.ApiKey())
+	}
+	if err := s.UpdateCredentials("key3", ""); err == nil {
+		t.Error("empty secrets should be rejected")
+	}
+
+	// Each request is consistently signed while credentials change
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			if err := s.Rename("logo", "brand", "", ImageType); err != nil {
+				t.Error(err)
+			}
+			if err := s.Ping(); err != nil {
+				t.Error(err)
+			}
+		}()
+		go func(i int) {
+			defer wg.Done()
+			key := []string{"key", "key2"}[i%2]
+			s.UpdateCredentials(key, secrets[key])
+		}(i)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,295 @@
+// Copyright 2013 Mathias Monnerville and Anthony Baillard.
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cloudinary
+
+import (
+	"bufio"
+	"compress/gzip"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// URLOptions sets how a resource is delivered.
+type URLOptions struct {
+	// Transformation applied on delivery, e.g. "w_300,h_200,c_fill".
+	// See Transformation to build complex ones.
+	Transformation string
+	// Version pins a version of the resource, bypassing CDN copies of
+	// other versions. Zero means the latest version.
+	Version int
+	// Format is the file extension of the delivered resource, e.g.
+	// "webp". Empty means the uploaded format.
+	Format string
+	// Page is the page of a multi-page PDF or TIFF file to deliver,
+	// starting at 1. Zero means the first page. Set Format to "jpg" or
+	// "png" to rasterize it.
+	Page int
+	// NoDefaultTransformation leaves out the transformation set with
+	// SetDefaultTransformation.
+	NoDefaultTransformation bool
+}
+
+// SetSecureDistribution sets the host delivering resources, e.g. a
+// custom CNAME such as assets.example.com, instead of
+// res.cloudinary.com. An empty host restores the default.
+func (s *Service) SetSecureDistribution(host string) error {
+	host = strings.TrimSpace(host)
+	if strings.ContainsAny(host, "/?#@") {
+		return fmt.Errorf("invalid delivery host %q", host)
+	}
+	s.deliveryHost = host
+	return nil
+}
+
+// SetPrivateCDN sets whether resources are delivered by a private CDN,
+// whose URLs don't hold the cloud name: by default
+// https://<cloud name>-res.cloudinary.com, or the host set with
+// SetSecureDistribution.
+func (s *Service) SetPrivateCDN(v bool) {
+	s.privateCDN = v
+}
+
+// SetLongURLSignature sets whether signed delivery URLs carry a 32
+// character SHA-256 signature instead of the default 8 character one.
+// Long signatures must be enabled for the Cloudinary account first, or
+// the signed URLs are rejected.
+func (s *Service) SetLongURLSignature(v bool) {
+	s.longURLSig = v
+}
+
+// SetDefaultTransformation sets a transformation, e.g. "f_auto,q_auto",
+// prepended to the transformation of all image and video delivery URLs,
+// so that delivery defaults apply everywhere. Raw files can't be
+// transformed and are left out. An empty t removes it.
+func (s *Service) SetDefaultTransformation(t string) error {
+	if _, err := ParseTransformation(t); err != nil {
+		return fmt.Errorf("invalid default transformation %q: %s", t, err)
+	}
+	s.defaultTrans = strings.Trim(t, "/")
+	return nil
+}
+
+// withDefaultTransformation returns t preceded by the default
+// transformation, if any.
+func (s *Service) withDefaultTransformation(t string) string {
+	if s.defaultTrans == "" {
+		return t
+	}
+	return strings.TrimSuffix(s.defaultTrans+"/"+t, "/")
+}
+
+// transformable tells whether resources of type rtype can be delivered
+// with a transformation.
+func transformable(rtype ResourceType) bool {
+	switch rtype {
+	case ImageType, PdfType, VideoType:
+		return true
+	}
+	return false
+}
+
+// deliveryBase returns the URL prefix of delivered resources, up to the
+// resource type.
+func (s *Service) deliveryBase() string {
+	host := s.deliveryHost
+	if host == "" {
+		if !s.privateCDN {
+			return baseResourceUrl + "/" + s.cloudName
+		}
+		host = s.cloudName + "-res.cloudinary.com"
+	}
+	if s.privateCDN {
+		return "https://" + host
+	}
+	return "https://" + host + "/" + s.cloudName
+}
+
+// DeliveryURL returns the URL of the publicId resource delivered with
+// opts, which can be nil.
+func (s *Service) DeliveryURL(publicId string, rtype ResourceType, opts *URLOptions) string {
+	return s.deliveryURL(publicId, rtype, opts, false)
+}
+
+// SignedURL returns the signed URL of the publicId resource delivered
+// with opts, which can be nil. Signed URLs are needed for
+// authenticated resources and strict transformations. The version is
+// not part of the signature.
+func (s *Service) SignedURL(publicId string, rtype ResourceType, opts *URLOptions) string {
+	return s.deliveryURL(publicId, rtype, opts, true)
+}
+
+func (s *Service) deliveryURL(publicId string, rtype ResourceType, opts *URLOptions, signed bool) string {
+	if opts == nil {
+		opts = &URLOptions{}
+	}
+	publicId = strings.TrimPrefix(publicId, "/")
+	if opts.Format != "" {
+		publicId += "." + opts.Format
+	}
+	transformation := opts.Transformation
+	if !opts.NoDefaultTransformation && transformable(rtype) {
+		transformation = s.withDefaultTransformation(transformation)
+	}
+	if opts.Page > 0 {
+		// The page is selected before any other transformation
+		transformation = strings.TrimSuffix(fmt.Sprintf("pg_%d/%s", opts.Page, transformation), "/")
+	}
+	parts := []string{s.deliveryBase(), resourceTypeName(rtype), "upload"}
+	if signed {
+		parts = append(parts, s.deliverySignature(transformation, publicId))
+	}
+	if transformation != "" {
+		parts = append(parts, transformation)
+	}
+	if opts.Version > 0 {
+		parts = append(parts, fmt.Sprintf("v%d", opts.Version))
+	}
+	return strings.Join(append(parts, publicId), "/")
+}
+
+// Download writes the content of the publicId resource, as delivered
+// from its URL without the default transformation, to w. With
+// decompress, a gzipped content, e.g. of a resource uploaded with the
+// Compress option, is decompressed; other contents are written as is.
+func (s *Service) Download(w io.Writer, publicId string, rtype ResourceType, decompress bool) error {
+	resp, err := s.client.Get(s.DeliveryURL(publicId, rtype, &URLOptions{NoDefaultTransformation: true}))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err := checkResponse(resp); err != nil {
+		return err
+	}
+	var body io.Reader = resp.Body
+	if decompress && !resp.Uncompressed {
+		br := bufio.NewReader(resp.Body)
+		if magic, err := br.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+			zr, err := gzip.NewReader(br)
+			if err != nil {
+				return err
+			}
+			defer zr.Close()
+			body = zr
+		} else {
+			body = br
+		}
+	}
+	_, err = io.Copy(w, body)
+	return err
+}
+
+// FetchURL returns the signed URL delivering the remote image at
+// remoteURL through Cloudinary's fetch proxy, with the transformation
+// applied if not empty, after the default transformation. remoteURL
+// must be an absolute http or https URL; it is escaped to fit in a path
+// segment.
+func (s *Service) FetchURL(remoteURL, transformation string) (string, error) {
+	u, err := url.Parse(remoteURL)
+	if err != nil {
+		return "", err
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", fmt.Errorf("remote URL %q must be an absolute http or https URL", remoteURL)
+	}
+	transformation = s.withDefaultTransformation(strings.Trim(transformation, "/"))
+	// The signature covers the source as it appears in the URL
+	source := fetchEscape(remoteURL)
+	parts := []string{s.deliveryBase(), imageType, "fetch", s.deliverySignature(transformation, source)}
+	if transformation != "" {
+		parts = append(parts, transformation)
+	}
+	return strings.Join(append(parts, source), "/"), nil
+}
+
+// PrivateDownloadURL returns a signed URL of the download API
+// delivering the publicId resource until expiresAt, e.g. to share a
+// private or authenticated file for a limited time. format, if not
+// empty, is the format to deliver, e.g. "pdf". The URL stops working
+// after expiresAt, which must be in the future.
+func (s *Service) PrivateDownloadURL(publicId, format string, rtype ResourceType, expiresAt time.Time) (string, error) {
+	now := s.now()
+	if !expiresAt.After(now) {
+		return "", fmt.Errorf("expiry %s is not in the future", expiresAt.Format(time.RFC3339))
+	}
+	params := url.Values{
+		"public_id":  []string{strings.TrimPrefix(publicId, "/")},
+		"timestamp":  []string{strconv.FormatInt(now.Unix(), 10)},
+		"expires_at": []string{strconv.FormatInt(expiresAt.Unix(), 10)},
+	}
+	if format != "" {
+		params.Set("format", format)
+	}
+	s.signParams(params)
+	return fmt.Sprintf("%s/%s/%s/download?%s", s.apiURL(), s.cloudName, resourceTypeName(rtype), params.Encode()), nil
+}
+
+// fetchEscape percent-encodes the bytes of a remote URL which are not
+// letters, digits or one of "_.-/:", as Cloudinary expects in fetch
+// URLs.
+func fetchEscape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || strings.IndexByte("_.-/:", c) >= 0 {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// deliverySignature returns the s--<signature>-- URL component signing
+// the transformation and public id. Long signatures always use SHA-256.
+func (s *Service) deliverySignature(transformation, publicId string) string {
+	toSign := publicId
+	if transformation != "" {
+		toSign = transformation + "/" + publicId
+	}
+	_, secret := s.credentials()
+	var sum []byte
+	n := 8
+	if s.longURLSig {
+		n = 32
+	}
+	if s.longURLSig || s.signatureAlgo == SignatureSHA256 {
+		h := sha256.Sum256([]byte(toSign + secret))
+		sum = h[:]
+	} else {
+		h := sha1.Sum([]byte(toSign + secret))
+		sum = h[:]
+	}
+	return "s--" + base64.URLEncoding.EncodeToString(sum)[:n] + "--"
+}
+
+// ExistsCDN reports whether the publicId resource exists with a HEAD
+// request on its delivery URL. Unlike Exists, it does not consume the
+// Admin API rate limit and transfers no body, but the CDN may answer
+// from a stale cache: a resource recently deleted can still be
+// reported, and one recently uploaded after a miss can be missing.
+// Authenticated and private resources are reported as errors.
+func (s *Service) ExistsCDN(publicId string, rtype ResourceType) (bool, error) {
+	resp, err := s.client.Head(s.DeliveryURL(publicId, rtype, &URLOptions{NoDefaultTransformation: true}))
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+	if err := checkResponse(resp); err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,401 @@
+// Copyright 2013 Mathias Monnerville and Anthony Baillard.
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+package cloudinary
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDeliveryURL(t *testing.T) {
+	s, err := NewService("cloudname", "key", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := "https://res.cloudinary.com/cloudname"
+	tests := []struct {
+		id     string
+		rtype  ResourceType
+		opts   *URLOptions
+		expect string
+	}{
+		{"sample", ImageType, nil, base + "/image/upload/sample"},
+		{"sample", ImageType, &URLOptions{Version: 1699999999}, base + "/image/upload/v1699999999/sample"},
+		{"/images/logo", ImageType, &URLOptions{Transformation: "w_300,h_200,c_fill", Version: 1699999999, Format: "webp"},
+			base + "/image/upload/w_300,h_200,c_fill/v1699999999/images/logo.webp"},
+		{"js/app.js", RawType, &URLOptions{Version: 3}, base + "/raw/upload/v3/js/app.js"},
+	}
+	for _, tt := range tests {
+		if u := s.DeliveryURL(tt.id, tt.rtype, tt.opts); u != tt.expect {
+			t.Errorf("expect %s, got %s", tt.expect, u)
+		}
+	}
+}
+
+func TestDefaultTransformation(t *testing.T) {
+	s, err := NewService("cloudname", "key", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetDefaultTransformation("if_w_gt_100"); err == nil {
+		t.Error("expect an error for an invalid default transformation")
+	}
+	if err := s.SetDefaultTransformation("f_auto,q_auto"); err != nil {
+		t.Fatal(err)
+	}
+	base := "https://res.cloudinary.com/cloudname/image/upload/"
+	tests := []struct {
+		opts   *URLOptions
+		expect string
+	}{
+		{nil, base + "f_auto,q_auto/sample"},
+		{&URLOptions{Transformation: "w_300,c_scale"}, base + "f_auto,q_auto/w_300,c_scale/sample"},
+		{&URLOptions{Transformation: "w_300", Page: 2}, base + "pg_2/f_auto,q_auto/w_300/sample"},
+		{&URLOptions{Transformation: "w_300", NoDefaultTransformation: true}, base + "w_300/sample"},
+	}
+	for _, tt := range tests {
+		if u := s.DeliveryURL("sample", ImageType, tt.opts); u != tt.expect {
+			t.Errorf("expect %s, got %s", tt.expect, u)
+		}
+	}
+	u, err := s.FetchURL("https://example.com/x.jpg", "w_200")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(u, "/f_auto,q_auto/w_200/https://example.com/x.jpg") {
+		t.Errorf("the default transformation should be prepended, got %s", u)
+	}
+	if u := s.DeliveryURL("styles.css", RawType, nil); u != "https://res.cloudinary.com/cloudname/raw/upload/styles.css" {
+		t.Errorf("raw files can't be transformed, got %s", u)
+	}
+	if u := s.DeliveryURL("intro", VideoType, nil); u != "https://res.cloudinary.com/cloudname/video/upload/f_auto,q_auto/intro" {
+		t.Errorf("the default transformation should apply to videos, got %s", u)
+	}
+	s.SetDefaultTransformation("")
+	if u := s.DeliveryURL("sample", ImageType, nil); u != base+"sample" {
+		t.Errorf("expect no default transformation, got %s", u)
+	}
+}
+
+func TestDeliveryHost(t *testing.T) {
+	s, err := NewService("cloudname", "key", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		host    string
+		private bool
+		expect  string
+	}{
+		{"", false, "https://res.cloudinary.com/cloudname/image/upload/v3/sample.webp"},
+		{"", true, "https://cloudname-res.cloudinary.com/image/upload/v3/sample.webp"},
+		{"assets.example.com", true, "https://assets.example.com/image/upload/v3/sample.webp"},
+		{"cdn.example.com", false, "https://cdn.example.com/cloudname/image/upload/v3/sample.webp"},
+	}
+	for _, tt := range tests {
+		if err := s.SetSecureDistribution(tt.host); err != nil {
+			t.Fatal(err)
+		}
+		s.SetPrivateCDN(tt.private)
+		if u := s.DeliveryURL("sample", ImageType, &URLOptions{Version: 3, Format: "webp"}); u != tt.expect {
+			t.Errorf("expect %s, got %s", tt.expect, u)
+		}
+		if u := s.Url("sample", RawType); !strings.HasPrefix(u, strings.Split(tt.expect, "/image/")[0]+"/raw/upload/") {
+			t.Errorf("wrong Url() host for %q: %s", tt.host, u)
+		}
+	}
+	// The signature doesn't depend on the host
+	s.SetPrivateCDN(false)
+	signed := s.SignedURL("sample", ImageType, nil)
+	if !strings.HasPrefix(signed, "https://cdn.example.com/cloudname/image/upload/s--") {
+		t.Errorf("wrong signed URL %s", signed)
+	}
+	s.SetSecureDistribution("")
+	if def := s.SignedURL("sample", ImageType, nil); strings.TrimPrefix(def, "https://res.cloudinary.com") != strings.TrimPrefix(signed, "https://cdn.example.com") {
+		t.Errorf("signatures differ: %s and %s", def, signed)
+	}
+	if err := s.SetSecureDistribution("https://assets.example.com/"); err == nil {
+		t.Error("a URL should be rejected as host")
+	}
+}
+
+func TestSignedURL(t *testing.T) {
+	s, err := NewService("cloudname", "key", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := "https://res.cloudinary.com/cloudname/image/upload/"
+	if u, exp := s.SignedURL("sample", ImageType, &URLOptions{Format: "jpg"}), base+"s--dOz0MdKK--/sample.jpg"; u != exp {
+		t.Errorf("expect %s, got %s", exp, u)
+	}
+	// The version is not signed
+	opts := &URLOptions{Transformation: "w_300,h_200,c_fill", Version: 1699999999}
+	if u, exp := s.SignedURL("images/logo", ImageType, opts), base+"s--vgguZfhq--/w_300,h_200,c_fill/v1699999999/images/logo"; u != exp {
+		t.Errorf("expect %s, got %s", exp, u)
+	}
+	s.SetSignatureAlgorithm(SignatureSHA256)
+	if u, exp := s.SignedURL("sample", ImageType, &URLOptions{Format: "jpg"}), base+"s--3W2palyU--/sample.jpg"; u != exp {
+		t.Errorf("expect %s, got %s", exp, u)
+	}
+	// Long signatures are SHA-256 whatever the algorithm
+	s.SetLongURLSignature(true)
+	for _, algo := range []string{SignatureSHA1, SignatureSHA256} {
+		s.SetSignatureAlgorithm(algo)
+		if u, exp := s.SignedURL("sample", ImageType, &URLOptions{Format: "jpg"}), base+"s--3W2palyUV9GXseRkSNH_25BRW7BYu-WO--/sample.jpg"; u != exp {
+			t.Errorf("%s: expect %s, got %s", algo, exp, u)
+		}
+	}
+}
+
+func TestPrivateDownloadURL(t *testing.T) {
+	fakeClock(t)
+	defer restoreClock()
+	s, err := NewService("cloudname", "key", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := timeNow()
+	u, err := s.PrivateDownloadURL("/docs/report", "pdf", RawType, now.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pu, err := url.Parse(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if base := "https://api.cloudinary.com/v1_1/cloudname/raw/download"; !strings.HasPrefix(u, base+"?") {
+		t.Errorf("expect a URL starting with %s, got %s", base, u)
+	}
+	// The expiry is signed with the other parameters
+	exp := url.Values{
+		"public_id":  {"docs/report"},
+		"format":     {"pdf"},
+		"timestamp":  {"1500000000"},
+		"expires_at": {"1500003600"},
+		"api_key":    {"key"},
+		"signature":  {"485392bdc19505e45a76d6bdb355e6a135a0bcb5"},
+	}
+	if got := pu.Query(); got.Encode() != exp.Encode() {
+		t.Errorf("expect params %s, got %s", exp.Encode(), got.Encode())
+	}
+
+	for _, at := range []time.Time{now, now.Add(-time.Minute)} {
+		if _, err := s.PrivateDownloadURL("docs/report", "", RawType, at); err == nil {
+			t.Errorf("expiry %s should be rejected", at)
+		}
+	}
+}
+
+func TestFetchURL(t *testing.T) {
+	s, err := NewService("cloudname", "key", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := "https://res.cloudinary.com/cloudname/image/fetch/"
+	tests := []struct {
+		remote, transformation, expect string
+	}{
+		{"https://example.com/x.jpg", "w_200", base + "s--duKwHOFI--/w_200/https://example.com/x.jpg"},
+		// Signed as escaped in the URL
+		{"https://example.com/a b/photo.jpg?size=large&v=2", "", base + "s--JknKuOhZ--/https://example.com/a%20b/photo.jpg%3Fsize%3Dlarge%26v%3D2"},
+		{"https://example.com/x.jpg?v=2", "w_200", base + "s--5rnTbZ5X--/w_200/https://example.com/x.jpg%3Fv%3D2"},
+	}
+	for _, tt := range tests {
+		u, err := s.FetchURL(tt.remote, tt.transformation)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if u != tt.expect {
+			t.Errorf("expect %s, got %s", tt.expect, u)
+		}
+	}
+	for in, exp := range map[string]string{
+		"http://example.com/caf%C3%A9.png#top": "http://example.com/caf%25C3%25A9.png%23top",
+		"https://example.com/é+ü.png":          "https://example.com/%C3%A9%2B%C3%BC.png",
+		"https://user@example.com:8080/~a,b":   "https://user%40example.com:8080/%7Ea%2Cb",
+	} {
+		if e := fetchEscape(in); e != exp {
+			t.Errorf("%s: expect %s, got %s", in, exp, e)
+		}
+	}
+	for _, remote := range []string{"", "example.com/x.jpg", "ftp://example.com/x.jpg", "/x.jpg"} {
+		if _, err := s.FetchURL(remote, ""); err == nil {
+			t.Errorf("%q should be rejected", remote)
+		}
+	}
+}
+
+func TestOfflineSignedURL(t *testing.T) {
+	s, err := NewService("cloudname", "key", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.SetOffline()
+	base := "https://res.cloudinary.com/cloudname/image/upload/"
+	opts := &URLOptions{Transformation: "w_300,h_200,c_fill", Version: 1699999999}
+	if u, exp := s.SignedURL("images/logo", ImageType, opts), base+"s--vgguZfhq--/w_300,h_200,c_fill/v1699999999/images/logo"; u != exp {
+		t.Errorf("expect %s, got %s", exp, u)
+	}
+	if m := s.Metrics(); m.Requests != 0 {
+		t.Errorf("no request should be sent, got %d", m.Requests)
+	}
+	_, err = s.Exists("images/logo", ImageType)
+	if ue, ok := err.(*url.Error); !ok || ue.Err != ErrOffline {
+		t.Errorf("expect %v, got %v", ErrOffline, err)
+	}
+}
+
+func TestPageURL(t *testing.T) {
+	s, err := NewService("cloudname", "key", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := "https://res.cloudinary.com/cloudname/image/upload/"
+	opts := &URLOptions{Page: 3, Format: "jpg"}
+	if u, exp := s.SignedURL("docs/report", ImageType, opts), base+"s--sqg00Y_6--/pg_3/docs/report.jpg"; u != exp {
+		t.Errorf("expect %s, got %s", exp, u)
+	}
+	opts.Transformation = "w_300"
+	if u, exp := s.SignedURL("docs/report", ImageType, opts), base+"s--klxQ9OBI--/pg_3/w_300/docs/report.jpg"; u != exp {
+		t.Errorf("expect %s, got %s", exp, u)
+	}
+}
+
+func TestExistsCDN(t *testing.T) {
+	s, ts := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "HEAD" {
+			t.Errorf("expect a HEAD request, got %s", r.Method)
+		}
+		switch r.URL.Path {
+		case "/cloudname/image/upload/logo":
+		case "/cloudname/image/upload/private":
+			w.WriteHeader(http.StatusUnauthorized)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	for _, c := range []struct {
+		publicId string
+		exists   bool
+		status   int
+	}{
+		{"logo", true, 0},
+		{"missing", false, 0},
+		{"private", false, http.StatusUnauthorized},
+	} {
+		found, err := s.ExistsCDN(c.publicId, ImageType)
+		if found != c.exists {
+			t.Errorf("%s: expect exists %v, got %v", c.publicId, c.exists, found)
+		}
+		if e, ok := err.(*APIError); c.status != 0 && (!ok || e.StatusCode != c.status) {
+			t.Errorf("%s: expect a %d error, got %v", c.publicId, c.status, err)
+		} else if c.status == 0 && err != nil {
+			t.Errorf("%s: %s", c.publicId, err)
+		}
+	}
+}
+
+func TestCompressRoundTrip(t *testing.T) {
+	var stored []byte
+	var headers string
+	s, ts := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" {
+			f, _, err := r.FormFile("file")
+			if err != nil {
+				t.Fatal(err)
+			}
+			stored, _ = ioutil.ReadAll(f)
+			headers = r.FormValue("headers")
+			fmt.Fprint(w, `{"public_id":"styles.css","resource_type":"raw"}`)
+			return
+		}
+		w.Write(stored)
+	}))
+	defer ts.Close()
+
+	content := []byte(strings.Repeat("body { color: #333; }\n", 100))
+	opts := &UploadOptions{Compress: true}
+	if _, err := s.UploadWithOptions("styles.css", nil, "", false, ImageType, opts); err == nil {
+		t.Error("only raw uploads can be compressed")
+	}
+	if _, err := s.UploadWithOptions("styles.css", bytes.NewReader(content), "", false, RawType, opts); err != nil {
+		t.Fatal(err)
+	}
+	if headers != "" {
+		t.Errorf("expect no delivery header, got %q", headers)
+	}
+	if len(stored) >= len(content) || stored[0] != 0x1f || stored[1] != 0x8b {
+		t.Errorf("expect gzipped content to be uploaded, got %d bytes", len(stored))
+	}
+
+	for _, c := range []struct {
+		decompress bool
+		exp        []byte
+	}{
+		{false, stored},
+		{true, content},
+	} {
+		var buf bytes.Buffer
+		if err := s.Download(&buf, "styles.css", RawType, c.decompress); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(buf.Bytes(), c.exp) {
+			t.Errorf("decompress %v: got %d bytes, expect %d", c.decompress, buf.Len(), len(c.exp))
+		}
+	}
+}
+
+func TestDeleteVersion(t *testing.T) {
+	var deleted string
+	s, ts := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/v1_1/cloudname/resources/image/upload/logo":
+			if r.URL.Query().Get("versions") != "true" {
+				t.Error("versions should be requested")
+			}
+			fmt.Fprint(w, `{"public_id":"logo","asset_id":"a1","version":1700000000,"versions":[
+				{"version_id":"v-old","version":"1699999999","restorable":true},
+				{"version_id":"v-cur","version":"1700000000","restorable":false}]}`)
+		case r.Method == "DELETE" && r.URL.Path == "/v1_1/cloudname/resources/backup/a1":
+			deleted = r.URL.Query().Get("version_ids[]")
+			fmt.Fprint(w, `{"deleted":["v-old"]}`)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer ts.Close()
+
+	if err := s.DeleteVersion("logo", 1699999999, ImageType); err != nil {
+		t.Fatal(err)
+	}
+	if deleted != "v-old" {
+		t.Errorf("wrong deleted version %q", deleted)
+	}
+
+	deleted = ""
+	s.Simulate(true)
+	if err := s.DeleteVersion("logo", 1699999999, ImageType); err != nil {
+		t.Fatal(err)
+	}
+	if deleted != "" {
+		t.Errorf("a simulated deletion should not be sent, got %q", deleted)
+	}
+	s.Simulate(false)
+
+	if err := s.DeleteVersion("logo", 1700000000, ImageType); err == nil {
+		t.Error("deleting the current version should fail")
+	}
+	if err := s.DeleteVersion("logo", 42, ImageType); err == nil {
+		t.Error("deleting an unknown version should fail")
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright 2013 Mathias Monnerville and Anthony Baillard.
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cloudinary
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// DerivedStats sums up the derived resources of an asset, i.e. the
+// transformed versions generated and stored by Cloudinary.
+type DerivedStats struct {
+	Count int // Number of derived resources
+	Size  int // Total size, in bytes
+}
+
+// DerivedInventory returns the stats of the derived resources of all
+// the rtype resources, by public id. Resources without any derived
+// resource are included with zero stats. Derived resources are only
+// listed in resource details so one request per resource is issued:
+// this can be slow on large accounts.
+func (s *Service) DerivedInventory(rtype ResourceType) (map[string]DerivedStats, error) {
+	all, err := s.doGetResources(rtype)
+	if err != nil {
+		return nil, err
+	}
+	inv := make(map[string]DerivedStats, len(all))
+	for _, r := range all {
+		d, err := s.doGetResourceDetails(r.PublicId, rtype, nil)
+		if err != nil {
+			return nil, err
+		}
+		inv[r.PublicId] = derivedStats(d)
+	}
+	return inv, nil
+}
+
+// derivedStats sums up the derived resources listed in details.
+func derivedStats(details *ResourceDetails) DerivedStats {
+	var st DerivedStats
+	for _, d := range details.Derived {
+		st.Count++
+		st.Size += d.Size
+	}
+	return st
+}
+
+// PurgeAllDerived deletes all the derived resources of the rtype
+// resources, original resources are left untouched. Derived resources
+// of resources protected by KeepFiles() are kept. It returns the number
+// of derived resources deleted and the storage freed, or that would be
+// in simulation mode. As with DerivedInventory(), one request per
+// resource is issued.
+func (s *Service) PurgeAllDerived(rtype ResourceType) (count int, freedBytes int64, err error) {
+	if err := s.writable(); err != nil {
+		return 0, 0, err
+	}
+	all, err := s.doGetResources(rtype)
+	if err != nil {
+		return 0, 0, err
+	}
+	ids := make([]string, 0)
+	purged := make([]string, 0)
+	for _, r := range all {
+		if s.Protected(r.PublicId) {
+			continue
+		}
+		d, err := s.doGetResourceDetails(r.PublicId, rtype, nil)
+		if err != nil {
+			return 0, 0, err
+		}
+		for _, dr := range d.Derived {
+			ids = append(ids, dr.Id)
+			freedBytes += int64(dr.Size)
+		}
+		if len(d.Derived) > 0 {
+			purged = append(purged, r.PublicId)
+		}
+	}
+	if s.simulate {
+		return len(ids), freedBytes, nil
+	}
+	for start := 0; start < len(ids); start += maxPublicIds {
+		end := start + maxPublicIds
+		if end > len(ids) {
+			end = len(ids)
+		}
+		if err := s.deleteDerived(ids[start:end]); err != nil {
+			return 0, 0, err
+		}
+	}
+	s.detailsCache.evict(purged...)
+	return len(ids), freedBytes, nil
+}
+
+// deleteDerived deletes derived resources by id, up to 100 at a time.
+func (s *Service) deleteDerived(ids []string) error {
+	qs := url.Values{"derived_resource_ids[]": ids}
+	req, err := http.NewRequest("DELETE", fmt.Sprintf("%s/derived_resources?%s", s.adminURL(), qs.Encode()), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	var m map[string]interface{}
+	return decodeResponse(resp, &m)
+}
@@ -0,0 +1,88 @@
+// Copyright 2013 Mathias Monnerville and Anthony Baillard.
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+package cloudinary
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestDerivedInventory(t *testing.T) {
+	s, ts := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1_1/cloudname/resources/image":
+			fmt.Fprint(w, `{"resources":[{"public_id":"cover"},{"public_id":"logo"},{"public_id":"icon"}]}`)
+		case "/v1_1/cloudname/resources/image/upload/cover":
+			fmt.Fprint(w, `{"public_id":"cover","derived":[
+				{"transformation":"c_fill,w_100","bytes":1000},
+				{"transformation":"c_fill,w_200","bytes":3000},
+				{"transformation":"c_fill,w_400","bytes":9000}]}`)
+		case "/v1_1/cloudname/resources/image/upload/logo":
+			fmt.Fprint(w, `{"public_id":"logo","derived":[{"transformation":"e_grayscale","bytes":500}]}`)
+		case "/v1_1/cloudname/resources/image/upload/icon":
+			fmt.Fprint(w, `{"public_id":"icon"}`)
+		default:
+			t.Errorf("unexpected request %s", r.URL.Path)
+		}
+	}))
+	defer ts.Close()
+
+	inv, err := s.DerivedInventory(ImageType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := map[string]DerivedStats{
+		"cover": {Count: 3, Size: 13000},
+		"logo":  {Count: 1, Size: 500},
+		"icon":  {},
+	}
+	if !reflect.DeepEqual(inv, exp) {
+		t.Errorf("wrong inventory. Expect %+v, got %+v", exp, inv)
+	}
+}
+
+func TestPurgeAllDerived(t *testing.T) {
+	var deleted []string
+	s, ts := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/v1_1/cloudname/resources/image":
+			fmt.Fprint(w, `{"resources":[{"public_id":"cover"},{"public_id":"logo"},{"public_id":"keep/hero"}]}`)
+		case r.URL.Path == "/v1_1/cloudname/resources/image/upload/cover":
+			fmt.Fprint(w, `{"public_id":"cover","derived":[{"id":"d1","bytes":1000},{"id":"d2","bytes":3000}]}`)
+		case r.URL.Path == "/v1_1/cloudname/resources/image/upload/logo":
+			fmt.Fprint(w, `{"public_id":"logo","derived":[{"id":"d3","bytes":500}]}`)
+		case r.Method == "DELETE" && r.URL.Path == "/v1_1/cloudname/derived_resources":
+			deleted = append(deleted, r.URL.Query()["derived_resource_ids[]"]...)
+			fmt.Fprint(w, `{"deleted":{}}`)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer ts.Close()
+	s.KeepFiles("^keep/")
+
+	s.Simulate(true)
+	count, freed, err := s.PurgeAllDerived(ImageType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 3 || freed != 4500 || len(deleted) != 0 {
+		t.Errorf("simulation: expect 3 resources and 4500 bytes without deletion, got %d, %d, %v", count, freed, deleted)
+	}
+
+	s.Simulate(false)
+	count, freed, err = s.PurgeAllDerived(ImageType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 3 || freed != 4500 {
+		t.Errorf("expect 3 resources and 4500 bytes, got %d and %d", count, freed)
+	}
+	if exp := []string{"d1", "d2", "d3"}; !reflect.DeepEqual(deleted, exp) {
+		t.Errorf("expect %v deleted, got %v", exp, deleted)
+	}
+}
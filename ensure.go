@@ -0,0 +1,64 @@
+// Copyright 2013 Mathias Monnerville and Anthony Baillard.
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cloudinary
+
+import (
+	"errors"
+)
+
+// EnsureResult tells which changes were made by Ensure(). In simulation
+// mode, it tells which changes would have been made.
+type EnsureResult struct {
+	Uploaded bool // The resource was missing and has been uploaded
+	Tagged   bool // The tag was missing and has been added
+}
+
+// Ensure makes sure the file at path is available under publicId and,
+// if tag is not empty, tagged with tag. The file is only uploaded if no
+// resource matches publicId, and only tagged if the tag is missing, so
+// that calling Ensure again makes no change. Existing resources are not
+// compared with the local file.
+func (s *Service) Ensure(path, publicId, tag string, rtype ResourceType) (*EnsureResult, error) {
+	if err := s.writable(); err != nil {
+		return nil, err
+	}
+	if publicId == "" {
+		return nil, errors.New("missing public id")
+	}
+	if tag != "" {
+		if err := validateTag(tag); err != nil {
+			return nil, err
+		}
+	}
+	res := new(EnsureResult)
+	details, err := s.doGetResourceDetails(publicId, rtype, nil)
+	if isNotFound(err) {
+		opts := &UploadOptions{PublicId: publicId}
+		if tag != "" {
+			opts.Tags = []string{tag}
+		}
+		if !s.simulate {
+			if _, err := s.UploadWithOptions(path, nil, "", false, rtype, opts); err != nil {
+				return nil, err
+			}
+		}
+		res.Uploaded = true
+		res.Tagged = tag != ""
+		return res, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if tag != "" && !details.resource().HasTag(tag) {
+		if !s.simulate {
+			if err := s.AddTag(tag, []string{publicId}, rtype); err != nil {
+				return nil, err
+			}
+		}
+		res.Tagged = true
+	}
+	return res, nil
+}
@@ -0,0 +1,154 @@
+// Copyright 2013 Mathias Monnerville and Anthony Baillard.
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+package cloudinary
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEnsure(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ensure")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "logo.png")
+	if err := ioutil.WriteFile(file, []byte("png"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		exists, tagged bool
+		expect         EnsureResult
+		calls          string
+	}{
+		{false, false, EnsureResult{Uploaded: true, Tagged: true}, "details,upload"},
+		{true, false, EnsureResult{Tagged: true}, "details,tags"},
+		{true, true, EnsureResult{}, "details"},
+	}
+	for _, tt := range tests {
+		calls := make([]string, 0)
+		var form string
+		s, ts := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/v1_1/cloudname/resources/image/upload/brand/logo":
+				calls = append(calls, "details")
+				if !tt.exists {
+					w.WriteHeader(http.StatusNotFound)
+					fmt.Fprint(w, `{"error":{"message":"Resource not found - brand/logo"}}`)
+					return
+				}
+				tags := `["other"]`
+				if tt.tagged {
+					tags = `["other","brand"]`
+				}
+				fmt.Fprintf(w, `{"public_id":"brand/logo","tags":%s}`, tags)
+			case "/v1_1/cloudname/image/upload/":
+				calls = append(calls, "upload")
+				r.ParseMultipartForm(1 << 20)
+				form = fmt.Sprintf("public_id=%s tags=%s", r.FormValue("public_id"), r.FormValue("tags"))
+				fmt.Fprint(w, `{"public_id":"brand/logo","version":1,"resource_type":"image","tags":["brand"]}`)
+			case "/v1_1/cloudname/image/tags":
+				calls = append(calls, "tags")
+				r.ParseForm()
+				form = fmt.Sprintf("command=%s tag=%s public_ids=%s", r.FormValue("command"), r.FormValue("tag"), strings.Join(r.PostForm["public_ids[]"], ","))
+				fmt.Fprint(w, `{"public_ids":["brand/logo"]}`)
+			default:
+				t.Errorf("unexpected path %s", r.URL.Path)
+			}
+		}))
+
+		res, err := s.Ensure(file, "brand/logo", "brand", ImageType)
+		ts.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if *res != tt.expect {
+			t.Errorf("exists=%v tagged=%v: expect %+v, got %+v", tt.exists, tt.tagged, tt.expect, *res)
+		}
+		if c := strings.Join(calls, ","); c != tt.calls {
+			t.Errorf("exists=%v tagged=%v: expect calls %s, got %s", tt.exists, tt.tagged, tt.calls, c)
+		}
+		switch {
+		case !tt.exists && form != "public_id=brand/logo tags=brand":
+			t.Errorf("wrong upload parameters: %s", form)
+		case tt.exists && !tt.tagged && form != "command=add tag=brand public_ids=brand/logo":
+			t.Errorf("wrong tag parameters: %s", form)
+		}
+	}
+}
+
+func TestSimulatedEnsure(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ensure")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "logo.png")
+	if err := ioutil.WriteFile(file, []byte("png"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		exists bool
+		expect EnsureResult
+	}{
+		{false, EnsureResult{Uploaded: true, Tagged: true}},
+		{true, EnsureResult{Tagged: true}},
+	}
+	for _, tt := range tests {
+		s, ts := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet {
+				t.Errorf("exists=%v: unexpected %s %s in simulation mode", tt.exists, r.Method, r.URL.Path)
+			}
+			if !tt.exists {
+				w.WriteHeader(http.StatusNotFound)
+				fmt.Fprint(w, `{"error":{"message":"Resource not found - brand/logo"}}`)
+				return
+			}
+			fmt.Fprint(w, `{"public_id":"brand/logo","tags":["other"]}`)
+		}))
+		s.Simulate(true)
+
+		res, err := s.Ensure(file, "brand/logo", "brand", ImageType)
+		ts.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if *res != tt.expect {
+			t.Errorf("exists=%v: expect %+v, got %+v", tt.exists, tt.expect, *res)
+		}
+	}
+}
+
+func TestExists(t *testing.T) {
+	s, ts := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1_1/cloudname/resources/image/upload/here":
+			fmt.Fprint(w, `{"public_id":"here"}`)
+		case "/v1_1/cloudname/resources/image/upload/gone":
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer ts.Close()
+
+	if ok, err := s.Exists("here", ImageType); !ok || err != nil {
+		t.Errorf("here should exist, got %v, %v", ok, err)
+	}
+	if ok, err := s.Exists("gone", ImageType); ok || err != nil {
+		t.Errorf("gone should not exist, got %v, %v", ok, err)
+	}
+	if _, err := s.Exists("broken", ImageType); err == nil {
+		t.Error("server errors should be reported")
+	}
+}
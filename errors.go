@@ -0,0 +1,106 @@
+// Copyright 2013 Mathias Monnerville and Anthony Baillard.
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cloudinary
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+// APIError is an error reported by the Cloudinary API.
+type APIError struct {
+	StatusCode int       // HTTP status code
+	Message    string    // Error message sent by Cloudinary
+	ServerTime time.Time // Date of the response, zero if unknown
+}
+
+func (e *APIError) Error() string {
+	return e.Message
+}
+
+// FileError is an error about a single file or resource of a batch
+// operation.
+type FileError struct {
+	Path string // Local path or public id
+	Err  error
+}
+
+func (e *FileError) Error() string {
+	return e.Path + ": " + e.Err.Error()
+}
+
+func (e *FileError) Unwrap() error {
+	return e.Err
+}
+
+// BatchError reports all the errors of a batch operation which went on
+// after errors. See SetFailFast().
+type BatchError struct {
+	errs []FileError
+}
+
+// Errors returns the errors, in the order they occurred.
+func (e *BatchError) Errors() []FileError {
+	return e.errs
+}
+
+func (e *BatchError) Error() string {
+	if len(e.errs) == 1 {
+		return e.errs[0].Error()
+	}
+	return fmt.Sprintf("%d errors, first one: %s", len(e.errs), e.errs[0].Error())
+}
+
+// checkResponse returns an *APIError if resp is not successful. The
+// error message is taken from the JSON body sent by Cloudinary, which
+// looks like {"error":{"message":"Missing required parameter - public_id"}}.
+// The HTTP status is used if there is no such message.
+func checkResponse(resp *http.Response) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	e := &APIError{StatusCode: resp.StatusCode, Message: resp.Status}
+	if t, err := http.ParseTime(resp.Header.Get("Date")); err == nil {
+		e.ServerTime = t
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return e
+	}
+	var msg struct {
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(body, &msg); err == nil && msg.Error.Message != "" {
+		e.Message = msg.Error.Message
+	}
+	return e
+}
+
+// isNotFound reports whether err is an API error for a missing resource.
+func isNotFound(err error) bool {
+	e, ok := err.(*APIError)
+	return ok && e.StatusCode == http.StatusNotFound
+}
+
+// decodeResponse decodes the JSON body of a successful response into v
+// and closes the body. The body is read until the end so that the
+// connection can be reused.
+func decodeResponse(resp *http.Response, v interface{}) error {
+	defer func() {
+		io.Copy(ioutil.Discard, resp.Body)
+		resp.Body.Close()
+	}()
+	if err := checkResponse(resp); err != nil {
+		return err
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
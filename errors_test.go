@@ -0,0 +1,65 @@
+// Copyright 2013 Mathias Monnerville and Anthony Baillard.
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+package cloudinary
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestCheckResponse(t *testing.T) {
+	tests := []struct {
+		status int
+		body   string
+		msg    string
+	}{
+		{400, `{"error":{"message":"Invalid transformation component - c_fil"}}`, "Invalid transformation component - c_fil"},
+		{401, `{"error":{"message":"Invalid Signature 5f3b. String to sign - 'public_id=logo&timestamp=1315060510'."}}`, "Invalid Signature 5f3b. String to sign - 'public_id=logo&timestamp=1315060510'."},
+		{404, `{"error":{"message":"Resource not found - images/logo"}}`, "Resource not found - images/logo"},
+		{500, `<html><body>Internal Server Error</body></html>`, "500 Internal Server Error"},
+		{502, ``, "502 Bad Gateway"},
+	}
+	for _, tt := range tests {
+		resp := &http.Response{
+			StatusCode: tt.status,
+			Status:     fmt.Sprintf("%d %s", tt.status, http.StatusText(tt.status)),
+			Body:       ioutil.NopCloser(strings.NewReader(tt.body)),
+		}
+		err := checkResponse(resp)
+		apiErr, ok := err.(*APIError)
+		if !ok {
+			t.Fatalf("expect an *APIError, got %T", err)
+		}
+		if apiErr.StatusCode != tt.status {
+			t.Errorf("wrong status code. Expect %d, got %d", tt.status, apiErr.StatusCode)
+		}
+		if apiErr.Error() != tt.msg {
+			t.Errorf("wrong message. Expect %q, got %q", tt.msg, apiErr.Error())
+		}
+	}
+
+	ok := &http.Response{StatusCode: 200, Body: ioutil.NopCloser(strings.NewReader(`{}`))}
+	if err := checkResponse(ok); err != nil {
+		t.Errorf("no error expected on success, got %s", err)
+	}
+}
+
+func TestAPIErrorFromService(t *testing.T) {
+	s, ts := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"error":{"message":"Resource not found - images/logo"}}`)
+	}))
+	defer ts.Close()
+	_, err := s.ResourceDetails("images/logo")
+	if err == nil || err.Error() != "Resource not found - images/logo" {
+		t.Errorf("wrong error, got %v", err)
+	}
+	if e, ok := err.(*APIError); !ok || e.StatusCode != http.StatusNotFound {
+		t.Errorf("expect a 404 *APIError, got %#v", err)
+	}
+}
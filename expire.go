@@ -0,0 +1,168 @@
+// Copyright 2013 Mathias Monnerville and Anthony Baillard.
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cloudinary
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DeleteOlderThan deletes the resources of type rtype whose public id
+// starts with prefix, or all of them if prefix is empty, uploaded more
+// than age ago. Protected resources are kept, see KeepFiles. Resources
+// are deleted by DeleteMany and the number of deleted resources is
+// returned, even on error. In simulation mode, the number of resources
+// which would be deleted is returned.
+func (s *Service) DeleteOlderThan(prefix string, age time.Duration, rtype ResourceType) (int, error) {
+	if err := s.writable(); err != nil {
+		return 0, err
+	}
+	if age <= 0 {
+		return 0, fmt.Errorf("invalid age %s", age)
+	}
+	var params url.Values
+	if prefix != "" {
+		params = url.Values{"prefix": []string{prefix}}
+	}
+	path := fmt.Sprintf("/resources/%s/upload", resourceTypeName(rtype))
+	res, _, err := s.doGetResourcesPage(path, params, "", 0)
+	if err != nil {
+		return 0, err
+	}
+	ids := make([]string, 0)
+	for _, r := range olderThan(res, s.now().Add(-age)) {
+		ids = append(ids, r.PublicId)
+	}
+	result, err := s.DeleteMany(ids, rtype)
+	return len(result.Deleted), err
+}
+
+// Number of batches of public ids deleted concurrently by DeleteMany
+const deleteWorkers = 4
+
+// DeleteMany deletes the resources of type rtype matching publicIds,
+// used as is. Ids are sent in batches of 100, the maximum allowed by
+// Cloudinary, at most 4 batches at a time. Protected resources are
+// kept, see KeepFiles. The result aggregates the outcome of each id,
+// sorted, and is returned even on error: the ids of failed batches are
+// then left out. In simulation mode, resources which would be deleted
+// are reported as deleted.
+func (s *Service) DeleteMany(publicIds []string, rtype ResourceType) (*DeleteResult, error) {
+	if err := s.writable(); err != nil {
+		return newDeleteResult(), err
+	}
+	result := newDeleteResult()
+	ids := make([]string, 0, len(publicIds))
+	for _, id := range publicIds {
+		if s.Protected(id) {
+			result.Protected = append(result.Protected, id)
+		} else {
+			ids = append(ids, id)
+		}
+	}
+	if s.simulate {
+		result.Deleted = append(result.Deleted, ids...)
+		return result, nil
+	}
+
+	var mu sync.Mutex
+	errs := make([]FileError, 0)
+	batches := make(chan []string)
+	var wg sync.WaitGroup
+	for w := 0; w < deleteWorkers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for batch := range batches {
+				deleted, notFound, err := s.deleteResources(batch, rtype)
+				mu.Lock()
+				result.Deleted = append(result.Deleted, deleted...)
+				result.NotFound = append(result.NotFound, notFound...)
+				if err != nil {
+					errs = append(errs, FileError{Path: batch[0], Err: err})
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for start := 0; start < len(ids); start += maxPublicIds {
+		mu.Lock()
+		failed := len(errs) > 0
+		mu.Unlock()
+		if failed && s.failFast {
+			break
+		}
+		end := start + maxPublicIds
+		if end > len(ids) {
+			end = len(ids)
+		}
+		batches <- ids[start:end]
+	}
+	close(batches)
+	wg.Wait()
+
+	sort.Strings(result.Deleted)
+	sort.Strings(result.NotFound)
+	sort.Strings(result.Protected)
+	switch {
+	case len(errs) == 0:
+		return result, nil
+	case s.failFast:
+		return result, &errs[0]
+	}
+	return result, &BatchError{errs}
+}
+
+// olderThan returns the resources created before cutoff. Resources
+// without creation date are left out.
+func olderThan(res []*Resource, cutoff time.Time) []*Resource {
+	old := make([]*Resource, 0)
+	for _, r := range res {
+		if !r.CreatedAt.IsZero() && r.CreatedAt.Before(cutoff) {
+			old = append(old, r)
+		}
+	}
+	return old
+}
+
+// deleteResources deletes at most 100 resources with a single admin API
+// request and returns the public ids actually deleted and those not
+// found.
+func (s *Service) deleteResources(publicIds []string, rtype ResourceType) ([]string, []string, error) {
+	qs := url.Values{"public_ids[]": publicIds}
+	uri := fmt.Sprintf("%s/resources/%s/upload?%s", s.adminURL(), resourceTypeName(rtype), qs.Encode())
+	req, err := http.NewRequest("DELETE", uri, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	var result struct {
+		Deleted map[string]string `json:"deleted"`
+	}
+	if err := decodeResponse(resp, &result); err != nil {
+		return nil, nil, err
+	}
+	var deleted, notFound []string
+	for id, status := range result.Deleted {
+		s.detailsCache.evict(id)
+		if status != "deleted" {
+			notFound = append(notFound, id)
+			continue
+		}
+		deleted = append(deleted, id)
+		if s.store != nil {
+			s.store.Delete(id)
+		}
+	}
+	return deleted, notFound, nil
+}
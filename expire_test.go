@@ -0,0 +1,184 @@
+// Copyright 2013 Mathias Monnerville and Anthony Baillard.
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+package cloudinary
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestOlderThan(t *testing.T) {
+	cutoff := time.Date(2017, 7, 14, 0, 0, 0, 0, time.UTC)
+	res := []*Resource{
+		{PublicId: "old", CreatedAt: cutoff.Add(-time.Second)},
+		{PublicId: "boundary", CreatedAt: cutoff},
+		{PublicId: "recent", CreatedAt: cutoff.Add(time.Second)},
+		{PublicId: "undated"},
+	}
+	old := olderThan(res, cutoff)
+	if len(old) != 1 || old[0].PublicId != "old" {
+		t.Errorf("expect only the resource created before the cutoff, got %v", old)
+	}
+}
+
+func TestDeleteOlderThan(t *testing.T) {
+	fakeClock(t)
+	defer restoreClock()
+	now := timeNow()
+	// 150 expired resources, deleted in 2 batches, one protected and one recent
+	res := make([]*Resource, 0)
+	for i := 0; i < 150; i++ {
+		res = append(res, &Resource{PublicId: fmt.Sprintf("temp/%03d", i), CreatedAt: now.Add(-31 * 24 * time.Hour)})
+	}
+	res = append(res,
+		&Resource{PublicId: "temp/keep", CreatedAt: now.Add(-365 * 24 * time.Hour)},
+		&Resource{PublicId: "temp/new", CreatedAt: now.Add(-29 * 24 * time.Hour)})
+	var prefix string
+	var batches [][]string
+	var mu sync.Mutex
+	s, ts := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.URL.Path != "/v1_1/cloudname/resources/image/upload" {
+			t.Errorf("wrong path %s", r.URL.Path)
+		}
+		if r.Method == "GET" {
+			prefix = r.URL.Query().Get("prefix")
+			json.NewEncoder(w).Encode(map[string]interface{}{"resources": res})
+			return
+		}
+		ids := r.URL.Query()["public_ids[]"]
+		batches = append(batches, ids)
+		deleted := make(map[string]string)
+		for _, id := range ids {
+			deleted[id] = "deleted"
+		}
+		if len(batches) == 2 {
+			deleted[ids[0]] = "not_found"
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"deleted": deleted})
+	}))
+	defer ts.Close()
+	s.KeepFiles("keep$")
+
+	s.Simulate(true)
+	n, err := s.DeleteOlderThan("temp/", 30*24*time.Hour, ImageType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 150 || len(batches) != 0 || prefix != "temp/" {
+		t.Errorf("expect 150 resources to delete with prefix temp/ and no request, got %d, %d batches, prefix %q", n, len(batches), prefix)
+	}
+
+	s.Simulate(false)
+	if n, err = s.DeleteOlderThan("temp/", 30*24*time.Hour, ImageType); err != nil {
+		t.Fatal(err)
+	}
+	// Batches are sent concurrently
+	sort.Slice(batches, func(i, j int) bool { return len(batches[i]) > len(batches[j]) })
+	if n != 149 || len(batches) != 2 || len(batches[0]) != 100 || len(batches[1]) != 50 {
+		t.Errorf("expect 149 resources deleted in batches of 100 and 50, got %d in %d batches", n, len(batches))
+	}
+	all := strings.Join(append(batches[0], batches[1]...), ",")
+	if strings.Contains(all, "temp/keep") || strings.Contains(all, "temp/new") {
+		t.Error("protected and recent resources should not be deleted")
+	}
+
+	if _, err := s.DeleteOlderThan("", 0, ImageType); err == nil {
+		t.Error("a zero age should be rejected")
+	}
+}
+
+func TestDeleteMany(t *testing.T) {
+	ids := make([]string, 0)
+	for i := 0; i < 250; i++ {
+		ids = append(ids, fmt.Sprintf("temp/%03d", i))
+	}
+	ids = append(ids, "temp/keep")
+	var mu sync.Mutex
+	var sizes []int
+	s, ts := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "DELETE" || r.URL.Path != "/v1_1/cloudname/resources/raw/upload" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		batch := r.URL.Query()["public_ids[]"]
+		mu.Lock()
+		sizes = append(sizes, len(batch))
+		mu.Unlock()
+		// Every tenth public id is missing
+		deleted := make(map[string]string)
+		for _, id := range batch {
+			deleted[id] = "deleted"
+			if strings.HasSuffix(id, "0") {
+				deleted[id] = "not_found"
+			}
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"deleted": deleted})
+	}))
+	defer ts.Close()
+	s.KeepFiles("keep$")
+
+	res, err := s.DeleteMany(ids, RawType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Ints(sizes)
+	if exp := []int{50, 100, 100}; !reflect.DeepEqual(sizes, exp) {
+		t.Errorf("expect batches of %v public ids, got %v", exp, sizes)
+	}
+	if len(res.Deleted) != 225 || len(res.NotFound) != 25 || !reflect.DeepEqual(res.Protected, []string{"temp/keep"}) {
+		t.Errorf("expect 225 deleted, 25 not found and 1 protected, got %d, %d and %v", len(res.Deleted), len(res.NotFound), res.Protected)
+	}
+	if res.Deleted[0] != "temp/001" || res.NotFound[0] != "temp/000" || res.NotFound[24] != "temp/240" {
+		t.Errorf("expect sorted public ids, got %v", res.NotFound)
+	}
+
+	s.Simulate(true)
+	sizes = nil
+	if res, err = s.DeleteMany(ids, RawType); err != nil {
+		t.Fatal(err)
+	}
+	if len(sizes) != 0 || len(res.Deleted) != 250 {
+		t.Errorf("expect 250 resources to delete and no request, got %d, %d requests", len(res.Deleted), len(sizes))
+	}
+}
+
+func TestDeleteManyErrors(t *testing.T) {
+	ids := make([]string, 300)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("temp/%03d", i)
+	}
+	s, ts := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		batch := r.URL.Query()["public_ids[]"]
+		if batch[0] == "temp/100" {
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, `{"error":{"message":"boom"}}`)
+			return
+		}
+		deleted := make(map[string]string)
+		for _, id := range batch {
+			deleted[id] = "deleted"
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"deleted": deleted})
+	}))
+	defer ts.Close()
+
+	res, err := s.DeleteMany(ids, ImageType)
+	var be *BatchError
+	if !errors.As(err, &be) || len(be.Errors()) != 1 || be.Errors()[0].Path != "temp/100" {
+		t.Fatalf("expect the failed batch to be reported, got %v", err)
+	}
+	if len(res.Deleted) != 200 {
+		t.Errorf("expect the other batches to be deleted, got %d", len(res.Deleted))
+	}
+}
@@ -0,0 +1,175 @@
+// Copyright 2013 Mathias Monnerville and Anthony Baillard.
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cloudinary
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	pathFolders = "/folders"
+)
+
+type folderList struct {
+	Folders []struct {
+		Name string `json:"name"`
+		Path string `json:"path"`
+	} `json:"folders"`
+}
+
+// folderURI returns the admin API URI of the folder at path.
+func (s *Service) folderURI(path string) (string, error) {
+	path = strings.Trim(path, "/")
+	segments := strings.Split(path, "/")
+	for i, seg := range segments {
+		if seg == "" {
+			return "", fmt.Errorf("invalid folder path %q", path)
+		}
+		segments[i] = url.PathEscape(seg)
+	}
+	return fmt.Sprintf("%s%s/%s", s.adminURL(), pathFolders, strings.Join(segments, "/")), nil
+}
+
+// Folders returns the paths of the sub-folders of the parent folder,
+// or of the root folders if parent is empty.
+func (s *Service) Folders(parent string) ([]string, error) {
+	uri := fmt.Sprintf("%s%s", s.adminURL(), pathFolders)
+	if strings.Trim(parent, "/") != "" {
+		var err error
+		if uri, err = s.folderURI(parent); err != nil {
+			return nil, err
+		}
+	}
+	resp, err := s.client.Get(uri)
+	if err != nil {
+		return nil, err
+	}
+	fl := new(folderList)
+	if err := decodeResponse(resp, fl); err != nil {
+		return nil, err
+	}
+	paths := make([]string, len(fl.Folders))
+	for i, f := range fl.Folders {
+		paths[i] = f.Path
+	}
+	return paths, nil
+}
+
+// CreateFolder creates the folder at path, along with missing parent
+// folders. Nothing is sent in simulation mode, as for DeleteFolder.
+func (s *Service) CreateFolder(path string) error {
+	return s.doFolderRequest("POST", path)
+}
+
+// DeleteFolder deletes the folder at path. The folder must be empty:
+// Cloudinary refuses to delete a folder holding resources.
+func (s *Service) DeleteFolder(path string) error {
+	return s.doFolderRequest("DELETE", path)
+}
+
+func (s *Service) doFolderRequest(method, path string) error {
+	if err := s.writable(); err != nil {
+		return err
+	}
+	if strings.Trim(path, "/") == "" {
+		return errors.New("missing folder path")
+	}
+	uri, err := s.folderURI(path)
+	if err != nil {
+		return err
+	}
+	if s.simulate {
+		return nil
+	}
+	req, err := http.NewRequest(method, uri, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	var m map[string]interface{}
+	return decodeResponse(resp, &m)
+}
+
+// FolderSummaryMax is the maximum number of resources fetched per
+// folder by FolderSummary to sum their size.
+const FolderSummaryMax = 5000
+
+// FolderStat sums up the resources of a folder, sub-folders included.
+type FolderStat struct {
+	Name       string // Folder path
+	AssetCount int
+	TotalBytes int64
+	// Truncated is set if the folder holds more than FolderSummaryMax
+	// resources: only the first ones are accounted for in TotalBytes.
+	Truncated bool
+}
+
+// FolderSummary returns the number and total size of the resources of
+// each root folder, sub-folders included.
+func (s *Service) FolderSummary() ([]FolderStat, error) {
+	return s.FolderSummaryDepth(1)
+}
+
+// FolderSummaryDepth is like FolderSummary, also summing up sub-folders
+// down to depth levels: 1 is root folders only, 2 adds their
+// sub-folders, and so on. Each folder costs at least a folder listing
+// and a search request.
+func (s *Service) FolderSummaryDepth(depth int) ([]FolderStat, error) {
+	if depth < 1 {
+		return nil, errors.New("depth must be at least 1")
+	}
+	var stats []FolderStat
+	var walk func(parent string, level int) error
+	walk = func(parent string, level int) error {
+		folders, err := s.Folders(parent)
+		if err != nil {
+			return err
+		}
+		for _, f := range folders {
+			st, err := s.folderStat(f)
+			if err != nil {
+				return err
+			}
+			stats = append(stats, st)
+			if level < depth {
+				if err := walk(f, level+1); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	if err := walk("", 1); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// folderStat counts the resources of the folder at path with the
+// search API, and sums the size of the first FolderSummaryMax ones.
+func (s *Service) folderStat(path string) (FolderStat, error) {
+	q := &searchQuery{
+		Expression: "folder:" + searchQuote(path+"/*"),
+		MaxResults: maxSearchResults,
+		SortBy:     []map[string]string{{"created_at": "asc"}},
+	}
+	res, total, err := s.search(q, FolderSummaryMax)
+	if err != nil {
+		return FolderStat{}, err
+	}
+	st := FolderStat{Name: path, AssetCount: total, Truncated: len(res) < total}
+	for _, r := range res {
+		st.TotalBytes += int64(r.Size)
+	}
+	return st, nil
+}
@@ -0,0 +1,141 @@
+// Copyright 2013 Mathias Monnerville and Anthony Baillard.
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+package cloudinary
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFolders(t *testing.T) {
+	var method, path string
+	s, ts := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method, path = r.Method, r.URL.EscapedPath()
+		switch {
+		case r.Method == "GET":
+			fmt.Fprint(w, `{"folders":[{"name":"2023","path":"products/2023"},{"name":"2024","path":"products/2024"}]}`)
+		case r.Method == "DELETE" && strings.HasSuffix(r.URL.Path, "/full"):
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"error":{"message":"Folder is not empty"}}`)
+		default:
+			fmt.Fprint(w, `{"success":true}`)
+		}
+	}))
+	defer ts.Close()
+
+	folders, err := s.Folders("products")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != "/v1_1/cloudname/folders/products" {
+		t.Errorf("wrong list path %s", path)
+	}
+	if strings.Join(folders, ",") != "products/2023,products/2024" {
+		t.Errorf("wrong folders %v", folders)
+	}
+	if _, err := s.Folders(""); err != nil || path != "/v1_1/cloudname/folders" {
+		t.Errorf("wrong root folders path %s (%v)", path, err)
+	}
+
+	if err := s.CreateFolder("/products/summer sale/"); err != nil {
+		t.Fatal(err)
+	}
+	if method != "POST" || path != "/v1_1/cloudname/folders/products/summer%20sale" {
+		t.Errorf("wrong create request %s %s", method, path)
+	}
+
+	if err := s.DeleteFolder("products/2024"); err != nil {
+		t.Fatal(err)
+	}
+	if method != "DELETE" || path != "/v1_1/cloudname/folders/products/2024" {
+		t.Errorf("wrong delete request %s %s", method, path)
+	}
+
+	err = s.DeleteFolder("products/full")
+	if err == nil || err.Error() != "Folder is not empty" {
+		t.Errorf("expect a folder not empty error, got %v", err)
+	}
+	if err := s.DeleteFolder("/"); err == nil {
+		t.Error("should fail without a folder path")
+	}
+
+	method, path = "", ""
+	s.Simulate(true)
+	if err := s.CreateFolder("products/2025"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.DeleteFolder("products/2023"); err != nil {
+		t.Fatal(err)
+	}
+	if method != "" {
+		t.Errorf("no request should be sent in simulation mode, got %s %s", method, path)
+	}
+	s.Simulate(false)
+}
+
+func TestFolderSummary(t *testing.T) {
+	searches := 0
+	s, ts := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1_1/cloudname/folders":
+			fmt.Fprint(w, `{"folders":[{"name":"photos","path":"photos"},{"name":"docs","path":"docs"}]}`)
+		case "/v1_1/cloudname/folders/photos":
+			fmt.Fprint(w, `{"folders":[{"name":"2020","path":"photos/2020"}]}`)
+		case "/v1_1/cloudname/folders/docs", "/v1_1/cloudname/folders/photos/2020":
+			fmt.Fprint(w, `{"folders":[]}`)
+		case "/v1_1/cloudname/resources/search":
+			searches++
+			var q searchQuery
+			if err := json.NewDecoder(r.Body).Decode(&q); err != nil {
+				t.Fatal(err)
+			}
+			switch q.Expression {
+			case `folder:"photos/*"`:
+				fmt.Fprint(w, `{"total_count":2,"resources":[{"public_id":"photos/a","bytes":100},{"public_id":"photos/2020/b","bytes":20}]}`)
+			case `folder:"photos/2020/*"`:
+				fmt.Fprint(w, `{"total_count":1,"resources":[{"public_id":"photos/2020/b","bytes":20}]}`)
+			case `folder:"docs/*"`:
+				// More resources than summed up: stop after FolderSummaryMax
+				page := strings.TrimSuffix(strings.Repeat(`{"public_id":"docs/x","bytes":1},`, FolderSummaryMax/2), ",")
+				fmt.Fprintf(w, `{"total_count":%d,"resources":[%s],"next_cursor":"c"}`, FolderSummaryMax*3, page)
+			default:
+				t.Errorf("unexpected search %q", q.Expression)
+			}
+		default:
+			t.Errorf("unexpected request %s", r.URL.Path)
+		}
+	}))
+	defer ts.Close()
+
+	stats, err := s.FolderSummary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := []FolderStat{
+		{Name: "photos", AssetCount: 2, TotalBytes: 120},
+		{Name: "docs", AssetCount: FolderSummaryMax * 3, TotalBytes: FolderSummaryMax, Truncated: true},
+	}
+	if !reflect.DeepEqual(stats, exp) {
+		t.Errorf("expect %+v, got %+v", exp, stats)
+	}
+	if searches != 3 {
+		t.Errorf("expect 3 searches, got %d", searches)
+	}
+
+	stats, err = s.FolderSummaryDepth(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats) != 3 || stats[1].Name != "photos/2020" || stats[1].AssetCount != 1 || stats[1].Truncated {
+		t.Errorf("wrong sub-folder stats %+v", stats)
+	}
+	if _, err := s.FolderSummaryDepth(0); err == nil {
+		t.Error("should fail with a zero depth")
+	}
+}
@@ -0,0 +1,168 @@
+// Copyright 2013 Mathias Monnerville and Anthony Baillard.
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cloudinary
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ExpandIDTemplate returns the public id of the localPath file given by
+// tmpl, e.g. "{dir}/{name}-{hash:8}{ext}". Placeholders are:
+//
+//	{dir}     directory of localPath relative to the current
+//	          directory, empty if it is the current directory
+//	{name}    file name without extension
+//	{ext}     file extension, with its dot
+//	{hash}    SHA1 hex digest of the file content
+//	{hash:N}  first N hex chars of the digest
+//	{date}    current UTC date, e.g. 2017-07-14
+//
+// Repeated slashes, e.g. from an empty {dir}, are squeezed and leading
+// or trailing slashes are trimmed. localPath must be inside the current
+// directory if {dir} is used.
+func ExpandIDTemplate(tmpl, localPath string) (string, error) {
+	return expandIDTemplate(tmpl, localPath, ".")
+}
+
+// expandIDTemplate is like ExpandIDTemplate, with {dir} relative to root
+// instead of the current directory.
+func expandIDTemplate(tmpl, localPath, root string) (string, error) {
+	var digest string
+	id, err := expandTemplate(tmpl, func(name, arg string) (string, error) {
+		switch name {
+		case "dir":
+			return relativeDir(localPath, root)
+		case "name":
+			base := filepath.Base(localPath)
+			return strings.TrimSuffix(base, filepath.Ext(base)), nil
+		case "ext":
+			return filepath.Ext(localPath), nil
+		case "date":
+			return timeNow().UTC().Format("2006-01-02"), nil
+		case "hash":
+			if digest == "" {
+				chk, err := fileChecksum(localPath)
+				if err != nil {
+					return "", err
+				}
+				digest = chk
+			}
+			n, _ := hashLength(arg)
+			return digest[:n], nil
+		}
+		return "", nil
+	})
+	if err != nil {
+		return "", err
+	}
+	for strings.Contains(id, "//") {
+		id = strings.Replace(id, "//", "/", -1)
+	}
+	id = strings.Trim(id, "/")
+	if id == "" {
+		return "", fmt.Errorf("%s: public id template %q expands to an empty id", localPath, tmpl)
+	}
+	return id, nil
+}
+
+// relativeDir returns the directory of path relative to root, with
+// forward slashes, empty if it is root.
+func relativeDir(path, root string) (string, error) {
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return "", err
+	}
+	abs, err := filepath.Abs(filepath.Dir(path))
+	if err != nil {
+		return "", err
+	}
+	dir, err := filepath.Rel(absRoot, abs)
+	if err != nil {
+		return "", err
+	}
+	dir = filepath.ToSlash(dir)
+	if dir == ".." || strings.HasPrefix(dir, "../") {
+		return "", fmt.Errorf("%s: {dir} can't expand outside of %s", path, root)
+	}
+	if dir == "." {
+		dir = ""
+	}
+	return dir, nil
+}
+
+// checkIDTemplate returns an error if tmpl holds an unknown or
+// malformed placeholder.
+func checkIDTemplate(tmpl string) error {
+	_, err := expandTemplate(tmpl, func(name, arg string) (string, error) {
+		return "", nil
+	})
+	return err
+}
+
+// expandTemplate replaces the {name} and {name:arg} placeholders of
+// tmpl with the values returned by f, after checking their syntax.
+func expandTemplate(tmpl string, f func(name, arg string) (string, error)) (string, error) {
+	var b strings.Builder
+	rest := tmpl
+	for {
+		start := strings.IndexAny(rest, "{}")
+		if start < 0 {
+			b.WriteString(rest)
+			return b.String(), nil
+		}
+		if rest[start] == '}' {
+			return "", fmt.Errorf("unexpected } in public id template %q", tmpl)
+		}
+		end := strings.IndexByte(rest[start:], '}')
+		if end < 0 {
+			return "", fmt.Errorf("unclosed placeholder in public id template %q", tmpl)
+		}
+		b.WriteString(rest[:start])
+		placeholder := rest[start+1 : start+end]
+		rest = rest[start+end+1:]
+
+		name, arg := placeholder, ""
+		if i := strings.IndexByte(placeholder, ':'); i >= 0 {
+			name, arg = placeholder[:i], placeholder[i+1:]
+		}
+		switch name {
+		case "dir", "name", "ext", "date":
+			if arg != "" {
+				return "", fmt.Errorf("placeholder {%s} takes no argument in public id template %q", name, tmpl)
+			}
+		case "hash":
+			if _, err := hashLength(arg); err != nil {
+				return "", fmt.Errorf("%s in public id template %q", err, tmpl)
+			}
+		default:
+			return "", fmt.Errorf("unknown placeholder {%s} in public id template %q", placeholder, tmpl)
+		}
+		v, err := f(name, arg)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(v)
+	}
+}
+
+// Length of the hex SHA1 digests expanded by {hash}
+const hashHexLength = 40
+
+// hashLength parses the N argument of {hash:N}, the full digest length
+// if empty.
+func hashLength(arg string) (int, error) {
+	if arg == "" {
+		return hashHexLength, nil
+	}
+	n, err := strconv.Atoi(arg)
+	if err != nil || n < 1 || n > hashHexLength {
+		return 0, fmt.Errorf("invalid hash length %q, must be between 1 and %d", arg, hashHexLength)
+	}
+	return n, nil
+}
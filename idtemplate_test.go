@@ -0,0 +1,179 @@
+// Copyright 2013 Mathias Monnerville and Anthony Baillard.
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+package cloudinary
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExpandIDTemplate(t *testing.T) {
+	fakeClock(t)
+	defer restoreClock()
+	dir, err := ioutil.TempDir("", "idtemplate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := os.Mkdir(filepath.Join(dir, "brand"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(dir, "brand", "logo.png")
+	if err := ioutil.WriteFile(file, []byte("logo"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	digest := fmt.Sprintf("%x", sha1.Sum([]byte("logo")))
+
+	tests := []struct {
+		tmpl, expect string
+	}{
+		{"{dir}", "brand"},
+		{"img/{name}", "img/logo"},
+		{"{name}{ext}", "logo.png"},
+		{"{hash}", digest},
+		{"{name}-{hash:8}", "logo-" + digest[:8]},
+		{"{date}/{name}", "2017-07-14/logo"},
+		{"/{dir}/{name}-{hash:8}{ext}/", "brand/logo-" + digest[:8] + ".png"},
+	}
+	for _, tt := range tests {
+		got, err := expandIDTemplate(tt.tmpl, file, dir)
+		if err != nil {
+			t.Errorf("%s: %s", tt.tmpl, err)
+		} else if got != tt.expect {
+			t.Errorf("%s: expect %q, got %q", tt.tmpl, tt.expect, got)
+		}
+	}
+	// A file in the root directory has an empty {dir}
+	if got, err := expandIDTemplate("{dir}/{name}", file, filepath.Join(dir, "brand")); err != nil || got != "logo" {
+		t.Errorf("expect logo, got %q (%v)", got, err)
+	}
+	if _, err := expandIDTemplate("{dir}/{name}", file, filepath.Join(dir, "other")); err == nil {
+		t.Error("expect an error expanding {dir} outside of the root")
+	}
+	// {dir} is relative to the current directory
+	for path, exp := range map[string]string{"logo.png": "logo", "brand/logo.png": "brand/logo", "./brand//logo.png": "brand/logo"} {
+		if got, err := ExpandIDTemplate("{dir}/{name}", path); err != nil || got != exp {
+			t.Errorf("%s: expect %s, got %q (%v)", path, exp, got, err)
+		}
+	}
+	if _, err := ExpandIDTemplate("{dir}/{name}", "../logo.png"); err == nil {
+		t.Error("expect an error expanding {dir} outside of the current directory")
+	}
+	if _, err := ExpandIDTemplate("{hash:8}", filepath.Join(dir, "missing.png")); err == nil {
+		t.Error("expect an error hashing a missing file")
+	}
+}
+
+func TestExpandIDTemplateErrors(t *testing.T) {
+	for _, tmpl := range []string{"{size}", "{Name}", "{}", "{name:x}", "{hash:0}", "{hash:41}", "{hash:x}", "{name", "name}", "{dir}"} {
+		if _, err := ExpandIDTemplate(tmpl, "logo.png"); err == nil {
+			t.Errorf("%s: expect an error", tmpl)
+		}
+	}
+	if _, err := ExpandIDTemplate("{size}", "logo.png"); err == nil || !strings.Contains(err.Error(), "unknown placeholder {size}") {
+		t.Errorf("expect an unknown placeholder error, got %v", err)
+	}
+	if err := checkIDTemplate("{dir}/{name}-{hash:8}{ext}"); err != nil {
+		t.Error(err)
+	}
+	for _, opts := range []*UploadOptions{
+		{IDTemplate: "{nme}"},
+		{IDTemplate: "{name}", PublicId: "logo"},
+		{IDTemplate: "{name}", PreserveStructure: true},
+	} {
+		if err := opts.validate(); err == nil {
+			t.Errorf("%+v: expect an error", opts)
+		}
+	}
+}
+
+func TestUploadIDTemplate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "idtemplate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	// Both map to the same template id
+	for _, name := range []string{"a.jpg", "b.jpg"} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte("same"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var ids []string
+	s, ts := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseMultipartForm(1 << 20)
+		ids = append(ids, r.FormValue("public_id"))
+		fmt.Fprint(w, `{"public_id":"x","version":1,"resource_type":"image"}`)
+	}), WithLogger(log.New(ioutil.Discard, "", 0)))
+	defer ts.Close()
+
+	digest := fmt.Sprintf("%x", sha1.Sum([]byte("same")))
+	opts := &UploadOptions{IDTemplate: "assets/{hash:10}", OnCollision: CollisionSuffix}
+	if _, err := s.UploadWithOptions(dir, nil, "ignored/", false, ImageType, opts); err != nil {
+		t.Fatal(err)
+	}
+	if exp := "assets/" + digest[:10] + ",assets/" + digest[:10] + "_1"; strings.Join(ids, ",") != exp {
+		t.Errorf("expect public ids %s, got %v", exp, ids)
+	}
+}
+
+func TestUploadIDTemplateDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "idtemplate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := os.Mkdir(filepath.Join(dir, "brand"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"a.jpg", "brand/b.jpg"} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var ids []string
+	s, ts := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseMultipartForm(1 << 20)
+		ids = append(ids, r.FormValue("public_id"))
+		fmt.Fprint(w, `{"public_id":"x","version":1,"resource_type":"image"}`)
+	}))
+	defer ts.Close()
+
+	// {dir} doesn't depend on the current directory
+	opts := &UploadOptions{IDTemplate: "assets/{dir}/{name}"}
+	if _, err := s.UploadWithOptions(dir, nil, "", false, ImageType, opts); err != nil {
+		t.Fatal(err)
+	}
+	if exp := "assets/a,assets/brand/b"; strings.Join(ids, ",") != exp {
+		t.Errorf("expect public ids %s, got %v", exp, ids)
+	}
+	ids = nil
+	if _, err := s.UploadWithOptions(filepath.Join(dir, "brand", "b.jpg"), nil, "", false, ImageType, opts); err != nil {
+		t.Fatal(err)
+	}
+	if exp := "assets/b"; strings.Join(ids, ",") != exp {
+		t.Errorf("expect public ids %s, got %v", exp, ids)
+	}
+
+	// Expansion errors fail the upload before anything is sent
+	ids = nil
+	if err := os.Symlink(filepath.Join(dir, "missing.jpg"), filepath.Join(dir, "broken.jpg")); err != nil {
+		t.Fatal(err)
+	}
+	opts = &UploadOptions{IDTemplate: "{hash:8}"}
+	if _, err := s.UploadWithOptions(dir, nil, "", false, ImageType, opts); err == nil {
+		t.Error("expect an error hashing a broken link")
+	}
+	if len(ids) != 0 {
+		t.Errorf("nothing should be uploaded, got %v", ids)
+	}
+}
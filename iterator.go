@@ -0,0 +1,90 @@
+// Copyright 2013 Mathias Monnerville and Anthony Baillard.
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cloudinary
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+// ResourceIterator lists resources one page at a time, so that large
+// inventories can be processed without holding them all in memory.
+// Pages are fetched lazily, following the next_cursor of the previous
+// one:
+//
+//	it := s.ResourceIterator(cloudinary.ImageType)
+//	for res, ok := it.Next(); ok; res, ok = it.Next() {
+//		fmt.Println(res.PublicId)
+//	}
+//	if err := it.Err(); err != nil {
+//		log.Fatal(err)
+//	}
+//
+// A ResourceIterator is not safe for concurrent use.
+type ResourceIterator struct {
+	s      *Service
+	path   string
+	page   []*Resource
+	cursor string
+	done   bool // The last page was fetched
+	err    error
+}
+
+// ResourceIterator returns an iterator over all the resources of type
+// rtype. No request is sent until Next is called.
+func (s *Service) ResourceIterator(rtype ResourceType) *ResourceIterator {
+	return &ResourceIterator{s: s, path: resourcesPath(rtype)}
+}
+
+// Next returns the next resource, fetching the next page if needed.
+// It returns false once all the resources have been listed or a page
+// could not be fetched; check Err to tell them apart.
+func (it *ResourceIterator) Next() (*Resource, bool) {
+	for len(it.page) == 0 {
+		if it.done || it.err != nil {
+			return nil, false
+		}
+		it.err = it.fetch()
+	}
+	res := it.page[0]
+	it.page = it.page[1:]
+	return res, true
+}
+
+// Err returns the error which stopped the iteration, if any.
+func (it *ResourceIterator) Err() error {
+	return it.err
+}
+
+// Cursor returns the cursor of the next page to fetch, empty before
+// the first page and after the last one. It can be passed to
+// ResourcesPage to resume a listing.
+func (it *ResourceIterator) Cursor() string {
+	return it.cursor
+}
+
+// fetch fetches the page at the current cursor.
+func (it *ResourceIterator) fetch() error {
+	qs := url.Values{
+		"max_results": []string{strconv.FormatInt(maxResults, 10)},
+		"tags":        []string{"true"},
+	}
+	if it.cursor != "" {
+		qs.Set("next_cursor", it.cursor)
+	}
+	resp, err := it.s.client.Get(fmt.Sprintf("%s%s?%s", it.s.adminURL(), it.path, qs.Encode()))
+	if err != nil {
+		return err
+	}
+	rs := new(resourceList)
+	if err := decodeResponse(resp, rs); err != nil {
+		return err
+	}
+	it.page, it.cursor = rs.Resources, rs.NextCursor
+	it.done = rs.NextCursor == ""
+	return nil
+}
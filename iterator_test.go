@@ -0,0 +1,90 @@
+// Copyright 2013 Mathias Monnerville and Anthony Baillard.
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+package cloudinary
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// pagedHandler serves ids two by two, with the index of the next page
+// as cursor. The page at failAt, if not empty, is an error.
+func pagedHandler(ids []string, failAt string, cursors *[]string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		cursor := r.URL.Query().Get("next_cursor")
+		*cursors = append(*cursors, cursor)
+		if failAt != "" && cursor == failAt {
+			http.Error(w, `{"error":{"message":"Rate Limit Exceeded"}}`, http.StatusTooManyRequests)
+			return
+		}
+		start, _ := strconv.Atoi(cursor)
+		end := start + 2
+		if end > len(ids) {
+			end = len(ids)
+		}
+		rl := resourceList{Resources: make([]*Resource, 0)}
+		for _, id := range ids[start:end] {
+			rl.Resources = append(rl.Resources, &Resource{PublicId: id})
+		}
+		if end < len(ids) {
+			rl.NextCursor = strconv.Itoa(end)
+		}
+		json.NewEncoder(w).Encode(rl)
+	}
+}
+
+func TestResourceIterator(t *testing.T) {
+	var cursors []string
+	s, ts := newTestService(t, pagedHandler([]string{"a", "b", "c", "d", "e"}, "", &cursors))
+	defer ts.Close()
+
+	it := s.ResourceIterator(ImageType)
+	if len(cursors) != 0 {
+		t.Error("no page should be fetched before Next")
+	}
+	var got []string
+	for res, ok := it.Next(); ok; res, ok = it.Next() {
+		got = append(got, res.PublicId)
+		if len(got) == 3 && it.Cursor() != "4" {
+			t.Errorf("expect cursor 4 while on the second page, got %q", it.Cursor())
+		}
+	}
+	if err := it.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if g := strings.Join(got, ","); g != "a,b,c,d,e" {
+		t.Errorf("expect a,b,c,d,e, got %s", g)
+	}
+	if c := strings.Join(cursors, ","); c != ",2,4" {
+		t.Errorf("expect pages fetched at cursors ,2,4, got %s", c)
+	}
+	if _, ok := it.Next(); ok || len(cursors) != 3 {
+		t.Error("an exhausted iterator should not fetch again")
+	}
+}
+
+func TestResourceIteratorError(t *testing.T) {
+	var cursors []string
+	s, ts := newTestService(t, pagedHandler([]string{"a", "b", "c", "d", "e"}, "2", &cursors))
+	defer ts.Close()
+
+	it := s.ResourceIterator(RawType)
+	var got []string
+	for res, ok := it.Next(); ok; res, ok = it.Next() {
+		got = append(got, res.PublicId)
+	}
+	if g := strings.Join(got, ","); g != "a,b" {
+		t.Errorf("expect the first page a,b, got %s", g)
+	}
+	if it.Err() == nil {
+		t.Fatal("the failure of the second page should be reported")
+	}
+	if _, ok := it.Next(); ok || len(cursors) != 2 {
+		t.Error("a failed iterator should not fetch again")
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright 2013 Mathias Monnerville and Anthony Baillard.
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cloudinary
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+)
+
+// BrokenLink is a resource whose delivery URL does not resolve.
+type BrokenLink struct {
+	Resource   *Resource
+	URL        string
+	StatusCode int   // Zero if the request failed
+	Err        error // Set if the request failed
+}
+
+// CheckLinks sends a HEAD request to the delivery URL of each resource,
+// with at most concurrency requests at a time, and returns the ones
+// not answering 200 OK, in the order of res. This catches resources
+// listed by the Admin API which can't be delivered, e.g. because their
+// access is restricted.
+func (s *Service) CheckLinks(res []*Resource, concurrency int) []*BrokenLink {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	broken := make([]*BrokenLink, len(res))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				broken[i] = s.checkLink(res[i])
+			}
+		}()
+	}
+	for i := range res {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	failed := make([]*BrokenLink, 0)
+	for _, b := range broken {
+		if b != nil {
+			failed = append(failed, b)
+		}
+	}
+	return failed
+}
+
+// checkLink returns nil if the delivery URL of r answers 200 OK.
+func (s *Service) checkLink(r *Resource) *BrokenLink {
+	b := &BrokenLink{Resource: r, URL: r.SecureUrl}
+	if b.URL == "" {
+		b.URL = r.Url
+	}
+	if b.URL == "" {
+		b.Err = errors.New("no delivery URL")
+		return b
+	}
+	resp, err := s.client.Head(b.URL)
+	if err != nil {
+		b.Err = err
+		return b
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+	b.StatusCode = resp.StatusCode
+	return b
+}
@@ -0,0 +1,61 @@
+// Copyright 2013 Mathias Monnerville and Anthony Baillard.
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+package cloudinary
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCheckLinks(t *testing.T) {
+	var mu sync.Mutex
+	inFlight, maxInFlight := 0, 0
+	s, ts := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "HEAD" {
+			t.Errorf("expect a HEAD request, got %s", r.Method)
+		}
+		mu.Lock()
+		if inFlight++; inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		mu.Unlock()
+		time.Sleep(5 * time.Millisecond)
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/private.png"):
+			w.WriteHeader(http.StatusUnauthorized)
+		case strings.HasSuffix(r.URL.Path, "/gone.png"):
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	var res []*Resource
+	for _, id := range []string{"a", "private", "b", "gone", "c", "d"} {
+		res = append(res, &Resource{PublicId: id, SecureUrl: "https://res.cloudinary.com/cloudname/image/upload/" + id + ".png"})
+	}
+	res = append(res, &Resource{PublicId: "nourl"})
+	broken := s.CheckLinks(res, 2)
+	if len(broken) != 3 {
+		t.Fatalf("expect 3 broken links, got %d", len(broken))
+	}
+	if b := broken[0]; b.Resource.PublicId != "private" || b.StatusCode != http.StatusUnauthorized || b.Err != nil {
+		t.Errorf("wrong access restricted link %+v", b)
+	}
+	if b := broken[1]; b.Resource.PublicId != "gone" || b.StatusCode != http.StatusNotFound {
+		t.Errorf("wrong missing link %+v", b)
+	}
+	if b := broken[2]; b.Resource.PublicId != "nourl" || b.Err == nil {
+		t.Errorf("wrong link without URL %+v", b)
+	}
+	if maxInFlight > 2 {
+		t.Errorf("expect at most 2 concurrent requests, got %d", maxInFlight)
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright 2013 Mathias Monnerville and Anthony Baillard.
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cloudinary
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	pathUploadMappings = "/upload_mappings"
+)
+
+// UploadMapping maps a folder to a remote URL prefix: resources
+// requested in the folder are fetched from the template URL on demand.
+type UploadMapping struct {
+	Folder   string `json:"folder"`
+	Template string `json:"template"` // URL prefix, e.g. https://example.com/images/
+}
+
+type uploadMappingList struct {
+	pagination
+	Mappings []UploadMapping `json:"mappings"`
+}
+
+// UploadMappings returns all the upload mappings of the account.
+func (s *Service) UploadMappings() ([]UploadMapping, error) {
+	qs := url.Values{}
+	mappings := make([]UploadMapping, 0)
+	for {
+		resp, err := s.client.Get(fmt.Sprintf("%s%s?%s", s.adminURL(), pathUploadMappings, qs.Encode()))
+		if err != nil {
+			return nil, err
+		}
+		ml := new(uploadMappingList)
+		if err := decodeResponse(resp, ml); err != nil {
+			return nil, err
+		}
+		mappings = append(mappings, ml.Mappings...)
+		if ml.NextCursor == "" {
+			break
+		}
+		qs.Set("next_cursor", ml.NextCursor)
+	}
+	return mappings, nil
+}
+
+// CreateUploadMapping maps folder to the template URL prefix.
+func (s *Service) CreateUploadMapping(folder, template string) error {
+	if err := s.writable(); err != nil {
+		return err
+	}
+	folder = strings.Trim(folder, "/")
+	if folder == "" {
+		return errors.New("missing mapping folder")
+	}
+	u, err := url.Parse(template)
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https" && u.Scheme != "s3" && u.Scheme != "gs") {
+		return fmt.Errorf("invalid mapping template %q, expect an absolute URL", template)
+	}
+	if s.simulate {
+		return nil
+	}
+	data := url.Values{
+		"folder":   []string{folder},
+		"template": []string{template},
+	}
+	resp, err := s.client.PostForm(fmt.Sprintf("%s%s", s.adminURL(), pathUploadMappings), data)
+	if err != nil {
+		return err
+	}
+	var m map[string]interface{}
+	return decodeResponse(resp, &m)
+}
+
+// DeleteUploadMapping deletes the upload mapping of folder.
+func (s *Service) DeleteUploadMapping(folder string) error {
+	if err := s.writable(); err != nil {
+		return err
+	}
+	folder = strings.Trim(folder, "/")
+	if folder == "" {
+		return errors.New("missing mapping folder")
+	}
+	if s.simulate {
+		return nil
+	}
+	qs := url.Values{"folder": []string{folder}}
+	req, err := http.NewRequest("DELETE", fmt.Sprintf("%s%s?%s", s.adminURL(), pathUploadMappings, qs.Encode()), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	var m map[string]interface{}
+	return decodeResponse(resp, &m)
+}
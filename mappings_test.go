@@ -0,0 +1,105 @@
+// Copyright 2013 Mathias Monnerville and Anthony Baillard.
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+package cloudinary
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestUploadMappings(t *testing.T) {
+	s, ts := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" || r.URL.Path != "/v1_1/cloudname/upload_mappings" {
+			t.Errorf("wrong request %s %s", r.Method, r.URL.Path)
+		}
+		if r.URL.Query().Get("next_cursor") == "" {
+			fmt.Fprint(w, `{"mappings":[{"folder":"wiki","template":"https://upload.wikimedia.org/wikipedia/"}],"next_cursor":"c1"}`)
+			return
+		}
+		fmt.Fprint(w, `{"mappings":[{"folder":"s3","template":"s3://bucket/assets/"}]}`)
+	}))
+	defer ts.Close()
+
+	mappings, err := s.UploadMappings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mappings) != 2 || mappings[0].Folder != "wiki" || mappings[1].Template != "s3://bucket/assets/" {
+		t.Errorf("wrong mappings %+v", mappings)
+	}
+}
+
+func TestCreateUploadMapping(t *testing.T) {
+	var method, path, folder, template string
+	s, ts := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method, path = r.Method, r.URL.Path
+		folder, template = r.FormValue("folder"), r.FormValue("template")
+		fmt.Fprint(w, `{"message":"created"}`)
+	}))
+	defer ts.Close()
+
+	if err := s.CreateUploadMapping("/wiki/", "https://upload.wikimedia.org/wikipedia/"); err != nil {
+		t.Fatal(err)
+	}
+	if method != "POST" || path != "/v1_1/cloudname/upload_mappings" {
+		t.Errorf("wrong create request %s %s", method, path)
+	}
+	if folder != "wiki" || template != "https://upload.wikimedia.org/wikipedia/" {
+		t.Errorf("wrong create parameters folder=%s template=%s", folder, template)
+	}
+	for _, tmpl := range []string{"", "wikipedia/", "ftp://host/dir"} {
+		if err := s.CreateUploadMapping("wiki", tmpl); err == nil {
+			t.Errorf("template %q should be rejected", tmpl)
+		}
+	}
+
+	method = ""
+	s.Simulate(true)
+	if err := s.CreateUploadMapping("books", "https://upload.wikimedia.org/wikibooks/"); err != nil {
+		t.Fatal(err)
+	}
+	if method != "" {
+		t.Errorf("no request should be sent in simulation mode, got %s %s", method, path)
+	}
+	s.Simulate(false)
+}
+
+func TestDeleteUploadMapping(t *testing.T) {
+	var method, path, folder string
+	s, ts := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method, path, folder = r.Method, r.URL.Path, r.URL.Query().Get("folder")
+		if folder == "missing" {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"error":{"message":"Mapping not found"}}`)
+			return
+		}
+		fmt.Fprint(w, `{"message":"deleted"}`)
+	}))
+	defer ts.Close()
+
+	if err := s.DeleteUploadMapping("wiki"); err != nil {
+		t.Fatal(err)
+	}
+	if method != "DELETE" || path != "/v1_1/cloudname/upload_mappings" || folder != "wiki" {
+		t.Errorf("wrong delete request %s %s folder=%s", method, path, folder)
+	}
+	if err := s.DeleteUploadMapping("missing"); !isNotFound(err) {
+		t.Errorf("expect a not found error, got %v", err)
+	}
+	if err := s.DeleteUploadMapping("/"); err == nil {
+		t.Error("empty folder should be rejected")
+	}
+
+	method = ""
+	s.Simulate(true)
+	if err := s.DeleteUploadMapping("wiki"); err != nil {
+		t.Fatal(err)
+	}
+	if method != "" {
+		t.Errorf("no request should be sent in simulation mode, got %s %s", method, path)
+	}
+	s.Simulate(false)
+}
@@ -0,0 +1,119 @@
+// Copyright 2013 Mathias Monnerville and Anthony Baillard.
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cloudinary
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Metrics is a snapshot of the activity of a service.
+type Metrics struct {
+	Requests       int64         // HTTP requests sent
+	APIErrors      int64         // Failed requests, or answered with an error status
+	RequestLatency time.Duration // Total time spent waiting for responses
+	Uploads        int64         // Files uploaded
+	BytesUploaded  int64         // Total size of the files uploaded
+}
+
+// MetricsObserver is notified of every request and upload, e.g. to
+// feed Prometheus counters and histograms. status is zero if the
+// request failed before a response was received.
+type MetricsObserver interface {
+	ObserveRequest(method string, status int, latency time.Duration)
+	ObserveUpload(bytes int64)
+}
+
+// WithMetricsObserver sets an observer notified of every request and
+// upload, in addition to the counters returned by Metrics().
+func WithMetricsObserver(o MetricsObserver) Option {
+	return func(s *Service) {
+		s.metrics.observer = o
+	}
+}
+
+// Metrics returns the activity counters of the service since it was
+// created. It is safe to call while requests are in flight.
+func (s *Service) Metrics() Metrics {
+	s.metrics.mu.Lock()
+	defer s.metrics.mu.Unlock()
+	return s.metrics.m
+}
+
+type metrics struct {
+	mu       sync.Mutex
+	m        Metrics
+	observer MetricsObserver
+}
+
+func (m *metrics) request(method string, status int, latency time.Duration) {
+	m.mu.Lock()
+	m.m.Requests++
+	if status == 0 || status >= 400 {
+		m.m.APIErrors++
+	}
+	m.m.RequestLatency += latency
+	m.mu.Unlock()
+	if m.observer != nil {
+		m.observer.ObserveRequest(method, status, latency)
+	}
+}
+
+func (m *metrics) upload(bytes int64) {
+	m.mu.Lock()
+	m.m.Uploads++
+	m.m.BytesUploaded += bytes
+	m.mu.Unlock()
+	if m.observer != nil {
+		m.observer.ObserveUpload(bytes)
+	}
+}
+
+// metricsTransport measures the requests sent through base, after
+// setting the identification headers, and traces them if enabled.
+type metricsTransport struct {
+	base    http.RoundTripper
+	metrics *metrics
+	ident   *identity
+	tracer  *tracer
+}
+
+func (t *metricsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if t.ident != nil {
+		req = t.ident.identify(req)
+	}
+	if t.tracer != nil && t.tracer.w != nil {
+		t.tracer.request(req)
+	}
+	start := timeNow()
+	resp, err := base.RoundTrip(req)
+	status := 0
+	if err == nil {
+		status = resp.StatusCode
+	}
+	t.metrics.request(req.Method, status, timeNow().Sub(start))
+	if t.tracer != nil && t.tracer.w != nil {
+		t.tracer.response(req, resp, err)
+	}
+	return resp, err
+}
+
+// instrumentClient makes the HTTP client report to the service metrics.
+// The client is copied so that a client given by the caller is not
+// modified.
+func (s *Service) instrumentClient() {
+	if _, ok := s.client.Transport.(*metricsTransport); ok {
+		return
+	}
+	c := *s.client
+	c.Transport = &metricsTransport{base: c.Transport, metrics: s.metrics, ident: s.ident, tracer: s.tracer}
+	s.client = &c
+}
@@ -0,0 +1,68 @@
+// Copyright 2013 Mathias Monnerville and Anthony Baillard.
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+package cloudinary
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testObserver struct {
+	requests []string
+	uploaded int64
+}
+
+func (o *testObserver) ObserveRequest(method string, status int, latency time.Duration) {
+	o.requests = append(o.requests, fmt.Sprintf("%s %d", method, status))
+}
+
+func (o *testObserver) ObserveUpload(bytes int64) {
+	o.uploaded += bytes
+}
+
+func TestMetrics(t *testing.T) {
+	obs := new(testObserver)
+	s, ts := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1_1/cloudname/ping":
+			fmt.Fprint(w, `{"status":"ok"}`)
+		case "/v1_1/cloudname/image/upload/":
+			if r.FormValue("public_id") == "bad" {
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprint(w, `{"error":{"message":"Invalid image file"}}`)
+				return
+			}
+			fmt.Fprint(w, `{"public_id":"logo","version":1,"resource_type":"image"}`)
+		}
+	}), WithMetricsObserver(obs))
+	defer ts.Close()
+
+	if m := s.Metrics(); m != (Metrics{}) {
+		t.Errorf("expect zero metrics, got %+v", m)
+	}
+	if err := s.Ping(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.UploadWithOptions("logo.png", strings.NewReader("12345"), "", false, ImageType, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.UploadWithOptions("bad.png", strings.NewReader("123"), "", false, ImageType, nil); err == nil {
+		t.Fatal("expect an upload error")
+	}
+
+	m := s.Metrics()
+	if m.Requests != 3 || m.APIErrors != 1 || m.Uploads != 1 || m.BytesUploaded != 5 {
+		t.Errorf("wrong metrics %+v", m)
+	}
+	if m.RequestLatency <= 0 {
+		t.Errorf("request latency should be measured, got %v", m.RequestLatency)
+	}
+	if r := strings.Join(obs.requests, ","); r != "GET 200,POST 200,POST 400" || obs.uploaded != 5 {
+		t.Errorf("wrong observations %s, %d bytes", r, obs.uploaded)
+	}
+}
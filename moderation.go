@@ -0,0 +1,64 @@
+// Copyright 2013 Mathias Monnerville and Anthony Baillard.
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cloudinary
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Moderation statuses of a resource.
+const (
+	ModerationPending  = "pending"
+	ModerationApproved = "approved"
+	ModerationRejected = "rejected"
+)
+
+// ModeratedResources returns the resources of type rtype queued for
+// moderation of the given kind, e.g. "manual" or "aws_rek", and having
+// the given status.
+func (s *Service) ModeratedResources(kind, status string, rtype ResourceType) ([]*Resource, error) {
+	if kind == "" || strings.Contains(kind, "/") {
+		return nil, fmt.Errorf("invalid moderation kind %q", kind)
+	}
+	switch status {
+	case ModerationPending, ModerationApproved, ModerationRejected:
+	default:
+		return nil, fmt.Errorf("invalid moderation status %q, must be %s, %s or %s", status, ModerationPending, ModerationApproved, ModerationRejected)
+	}
+	path := fmt.Sprintf("/resources/%s/moderations/%s/%s", resourceTypeName(rtype), kind, status)
+	allres, _, err := s.doGetResourcesPage(path, nil, "", 0)
+	return allres, err
+}
+
+// UpdateModeration approves or rejects the publicId resource, pending
+// manual moderation. status is ModerationApproved or ModerationRejected.
+// Nothing is sent in simulation mode.
+func (s *Service) UpdateModeration(publicId, status string, rtype ResourceType) error {
+	if err := s.writable(); err != nil {
+		return err
+	}
+	if status != ModerationApproved && status != ModerationRejected {
+		return fmt.Errorf("invalid moderation status %q, must be %s or %s", status, ModerationApproved, ModerationRejected)
+	}
+	if publicId == "" {
+		return errors.New("missing public id")
+	}
+	if s.simulate {
+		return nil
+	}
+	data := url.Values{"moderation_status": []string{status}}
+	uri := fmt.Sprintf("%s/resources/%s/upload/%s", s.adminURL(), resourceTypeName(rtype), publicId)
+	resp, err := s.client.PostForm(uri, data)
+	if err != nil {
+		return err
+	}
+	s.detailsCache.evict(publicId)
+	var m map[string]interface{}
+	return decodeResponse(resp, &m)
+}
@@ -0,0 +1,78 @@
+// Copyright 2013 Mathias Monnerville and Anthony Baillard.
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+package cloudinary
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestModeratedResources(t *testing.T) {
+	var path string
+	s, ts := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		fmt.Fprint(w, `{"resources":[{"public_id":"uploads/a","resource_type":"image"},{"public_id":"uploads/b","resource_type":"image"}]}`)
+	}))
+	defer ts.Close()
+
+	res, err := s.ModeratedResources("manual", ModerationPending, ImageType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != "/v1_1/cloudname/resources/image/moderations/manual/pending" {
+		t.Errorf("wrong path %s", path)
+	}
+	if len(res) != 2 || res[1].PublicId != "uploads/b" {
+		t.Errorf("wrong resources %v", res)
+	}
+	if _, err := s.ModeratedResources("aws_rek", ModerationRejected, RawType); err != nil {
+		t.Fatal(err)
+	}
+	if path != "/v1_1/cloudname/resources/raw/moderations/aws_rek/rejected" {
+		t.Errorf("wrong path %s", path)
+	}
+
+	path = ""
+	for _, c := range [][2]string{{"", ModerationPending}, {"manual/x", ModerationPending}, {"manual", "waiting"}} {
+		if _, err := s.ModeratedResources(c[0], c[1], ImageType); err == nil {
+			t.Errorf("kind %q and status %q should be rejected", c[0], c[1])
+		}
+	}
+	if path != "" {
+		t.Error("no request should be sent with invalid filters")
+	}
+}
+
+func TestUpdateModeration(t *testing.T) {
+	var method, path, status string
+	s, ts := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method, path, status = r.Method, r.URL.Path, r.FormValue("moderation_status")
+		fmt.Fprint(w, `{"public_id":"uploads/a"}`)
+	}))
+	defer ts.Close()
+
+	for _, st := range []string{ModerationApproved, ModerationRejected} {
+		if err := s.UpdateModeration("uploads/a", st, ImageType); err != nil {
+			t.Fatal(err)
+		}
+		if method != "POST" || path != "/v1_1/cloudname/resources/image/upload/uploads/a" || status != st {
+			t.Errorf("wrong request %s %s with status %q, expect %q", method, path, status, st)
+		}
+	}
+	if err := s.UpdateModeration("uploads/a", ModerationPending, ImageType); err == nil {
+		t.Error("resources can only be approved or rejected")
+	}
+
+	method = ""
+	s.Simulate(true)
+	if err := s.UpdateModeration("uploads/a", ModerationApproved, ImageType); err != nil {
+		t.Fatal(err)
+	}
+	if method != "" {
+		t.Errorf("no request should be sent in simulation mode, got %s %s", method, path)
+	}
+	s.Simulate(false)
+}
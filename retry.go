@@ -0,0 +1,106 @@
+// Copyright 2013 Mathias Monnerville and Anthony Baillard.
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cloudinary
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+// Delay before the first upload retry, doubled for each new attempt.
+const retryInterval = time.Second
+
+// SetUploadRetries sets how many times a failed upload is retried, on
+// network errors and on 429 or 5xx responses. Retries are disabled by
+// default. Uploads with a random public id are only retried if
+// idempotent uploads are enabled, see SetIdempotentUploads().
+func (s *Service) SetUploadRetries(n int) {
+	s.uploadRetries = n
+}
+
+// SetIdempotentUploads makes upload retries safe: if an upload timed
+// out but actually succeeded, retrying it must not create a duplicate.
+// Random public ids are generated client-side, so that all attempts of
+// an upload share the same public id, and overwrite=true is sent.
+func (s *Service) SetIdempotentUploads(v bool) {
+	s.idempotent = v
+}
+
+// randomPublicID returns a random public id like the ones generated by
+// Cloudinary.
+func randomPublicID() (string, error) {
+	b := make([]byte, 10)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// postUpload posts an upload form and checks the response, retrying up
+// to retries times on transient errors. The body of the returned
+// response must be closed.
+func (s *Service) postUpload(ctx context.Context, uri, contentType string, body []byte, retries int) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		resp, err := s.postUploadOnce(ctx, uri, contentType, body)
+		if err == nil || attempt >= retries || !retryable(err) || ctx.Err() != nil {
+			return resp, err
+		}
+		s.logger.Printf("Upload failed (%s), retrying\n", err)
+		timeSleep(retryInterval << uint(attempt))
+	}
+}
+
+func (s *Service) postUploadOnce(ctx context.Context, uri, contentType string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequest("POST", uri, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", contentType)
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkResponse(resp); err != nil {
+		io.Copy(ioutil.Discard, resp.Body)
+		resp.Body.Close()
+		return nil, err
+	}
+	return resp, nil
+}
+
+// retryable reports whether a failed request may succeed if retried:
+// network errors, rate limiting and server errors.
+func retryable(err error) bool {
+	e, ok := err.(*APIError)
+	if !ok {
+		return true
+	}
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
+// SetCleanupOnCancel deletes the resource being uploaded when an upload
+// is canceled, see UploadContext(), in case Cloudinary stored part of
+// it. Beware that if the upload was replacing an existing resource,
+// that resource is deleted too.
+func (s *Service) SetCleanupOnCancel(v bool) {
+	s.cleanupOnCancel = v
+}
+
+// cleanupCanceled deletes the publicId resource whose upload has been
+// canceled. Errors are only logged: the resource may not exist.
+func (s *Service) cleanupCanceled(publicId string) {
+	s.logger.Printf("Upload canceled, deleting %s\n", publicId)
+	if _, err := s.Delete(publicId, "", s.uploadResType, false); err != nil {
+		s.logger.Printf("Cleanup of %s failed: %s\n", publicId, err)
+	}
+}
@@ -0,0 +1,179 @@
+// Copyright 2013 Mathias Monnerville and Anthony Baillard.
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+package cloudinary
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIdempotentUploadRetry(t *testing.T) {
+	ids := make([]string, 0)
+	assets := make(map[string]int)
+	s, ts := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseMultipartForm(1 << 20)
+		id := r.PostForm.Get("public_id")
+		ids = append(ids, id)
+		if r.PostForm.Get("overwrite") != "true" {
+			t.Error("idempotent uploads should overwrite")
+		}
+		// The first attempt succeeds but the client sees a timeout
+		assets[id]++
+		if len(ids) == 1 {
+			w.WriteHeader(http.StatusGatewayTimeout)
+			return
+		}
+		fmt.Fprintf(w, `{"public_id":%q,"version":1,"resource_type":"image"}`, id)
+	}))
+	defer ts.Close()
+	sleeps := fakeClock(t)
+	defer restoreClock()
+	s.SetUploadRetries(2)
+	s.SetIdempotentUploads(true)
+
+	res, err := s.UploadWithOptions("logo.png", strings.NewReader("png"), "", true, ImageType, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 2 || ids[0] == "" || ids[0] != ids[1] || res.PublicId != ids[0] {
+		t.Errorf("all attempts should share a public id, got %q", ids)
+	}
+	if len(assets) != 1 {
+		t.Errorf("the retry created a duplicate: %v", assets)
+	}
+	if len(*sleeps) != 1 || (*sleeps)[0] != time.Second {
+		t.Errorf("expect a single 1s delay, got %v", *sleeps)
+	}
+}
+
+func TestUploadRetries(t *testing.T) {
+	status := []int{http.StatusServiceUnavailable, http.StatusTooManyRequests, http.StatusServiceUnavailable, http.StatusOK}
+	attempts := 0
+	s, ts := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status[attempts])
+		attempts++
+		fmt.Fprint(w, `{"public_id":"logo","version":1}`)
+	}))
+	defer ts.Close()
+	sleeps := fakeClock(t)
+	defer restoreClock()
+
+	// Random public ids aren't retried unless idempotent
+	s.SetUploadRetries(3)
+	if _, err := s.UploadWithOptions("logo.png", strings.NewReader("png"), "", true, ImageType, nil); err == nil {
+		t.Error("the error should be returned")
+	}
+	if attempts != 1 {
+		t.Errorf("expect a single attempt, got %d", attempts)
+	}
+
+	attempts = 0
+	if _, err := s.UploadWithOptions("logo.png", strings.NewReader("png"), "", false, ImageType, nil); err != nil {
+		t.Fatal(err)
+	}
+	if attempts != 4 {
+		t.Errorf("expect 4 attempts, got %d", attempts)
+	}
+	if exp := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second}; fmt.Sprint(*sleeps) != fmt.Sprint(exp) {
+		t.Errorf("expect delays %v, got %v", exp, *sleeps)
+	}
+
+	// Client errors are not retried
+	status, attempts = []int{http.StatusBadRequest}, 0
+	if _, err := s.UploadWithOptions("logo.png", strings.NewReader("png"), "", false, ImageType, nil); err == nil || attempts != 1 {
+		t.Errorf("expect a single failed attempt, got %d (%v)", attempts, err)
+	}
+}
+
+func TestUploadContextCleanup(t *testing.T) {
+	started := make(chan struct{})
+	destroyed := make(chan string, 1)
+	s, ts := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1_1/cloudname/image/destroy/" {
+			r.ParseForm()
+			destroyed <- r.PostForm.Get("public_id")
+			fmt.Fprint(w, `{"result":"ok"}`)
+			return
+		}
+		// Never completes, until the client gives up
+		ioutil.ReadAll(r.Body)
+		close(started)
+		<-r.Context().Done()
+	}))
+	defer ts.Close()
+	s.SetCleanupOnCancel(true)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-started
+		cancel()
+	}()
+	_, err := s.UploadContext(ctx, "logo.png", strings.NewReader("png"), "images/", false, ImageType, nil)
+	if err == nil || ctx.Err() == nil {
+		t.Fatalf("the upload should be canceled, got %v", err)
+	}
+	select {
+	case id := <-destroyed:
+		if id != "images/logo" {
+			t.Errorf("wrong public id deleted %s", id)
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("the canceled upload should be deleted")
+	}
+
+	// The folder of a preserved structure is part of the deleted id
+	started = make(chan struct{})
+	ctx, cancel = context.WithCancel(context.Background())
+	go func() {
+		<-started
+		cancel()
+	}()
+	opts := &UploadOptions{PreserveStructure: true}
+	if _, err := s.UploadContext(ctx, "site/img/x.png", strings.NewReader("png"), "", false, ImageType, opts); err == nil {
+		t.Fatal("the upload should be canceled")
+	}
+	select {
+	case id := <-destroyed:
+		if id != "site/img/x" {
+			t.Errorf("wrong public id deleted %s", id)
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("the canceled upload should be deleted")
+	}
+}
+
+func TestUploadContextDirectory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cloudinary")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for _, name := range []string{"a.png", "b.png", "c.png"} {
+		ioutil.WriteFile(filepath.Join(dir, name), []byte("png"), 0644)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	uploads := 0
+	s, ts := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		uploads++
+		// Interrupted after the first file
+		cancel()
+		fmt.Fprint(w, `{"public_id":"a","version":1}`)
+	}))
+	defer ts.Close()
+
+	if _, err := s.UploadContext(ctx, dir, nil, "", false, ImageType, nil); err != context.Canceled {
+		t.Errorf("expect %v, got %v", context.Canceled, err)
+	}
+	if uploads != 1 {
+		t.Errorf("the upload should stop after the first file, got %d uploads", uploads)
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright 2013 Mathias Monnerville and Anthony Baillard.
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cloudinary
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const (
+	pathSearch = "/resources/search"
+	// Maximum number of results per search request
+	maxSearchResults = 500
+)
+
+// searchEscaper escapes the characters special in a quoted search value.
+var searchEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+// searchQuote returns v as a quoted search value, e.g. "summer sale".
+func searchQuote(v string) string {
+	return `"` + searchEscaper.Replace(v) + `"`
+}
+
+type searchQuery struct {
+	Expression string              `json:"expression,omitempty"`
+	MaxResults int                 `json:"max_results"`
+	NextCursor string              `json:"next_cursor,omitempty"`
+	SortBy     []map[string]string `json:"sort_by"`
+	WithField  []string            `json:"with_field"`
+}
+
+type searchResult struct {
+	resourceList
+	TotalCount int `json:"total_count"`
+}
+
+// Search returns all the resources matching a search expression, such
+// as "resource_type:image AND tags=brand", oldest first. An empty
+// expression matches all resources. Results are paginated by
+// Cloudinary, so the full set of results is returned.
+func (s *Service) Search(expression string) ([]*Resource, error) {
+	q := &searchQuery{
+		Expression: expression,
+		MaxResults: maxSearchResults,
+		SortBy:     []map[string]string{{"created_at": "asc"}},
+		WithField:  []string{"tags"},
+	}
+	allres, _, err := s.search(q, 0)
+	return allres, err
+}
+
+// search runs q, following pagination until max resources are
+// fetched, or all of them if max is zero. The total count of matching
+// resources is also returned.
+func (s *Service) search(q *searchQuery, max int) ([]*Resource, int, error) {
+	allres := make([]*Resource, 0)
+	for {
+		body, err := json.Marshal(q)
+		if err != nil {
+			return nil, 0, err
+		}
+		req, err := http.NewRequest("POST", fmt.Sprintf("%s%s", s.adminURL(), pathSearch), bytes.NewReader(body))
+		if err != nil {
+			return nil, 0, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := s.client.Do(req)
+		if err != nil {
+			return nil, 0, err
+		}
+		rs := new(searchResult)
+		if err := decodeResponse(resp, rs); err != nil {
+			return nil, 0, err
+		}
+		allres = append(allres, rs.Resources...)
+		done := rs.NextCursor == "" || (max > 0 && len(allres) >= max)
+		if s.listProgress != nil {
+			s.listProgress(len(allres), done)
+		}
+		if done {
+			return allres, rs.TotalCount, nil
+		}
+		q.NextCursor = rs.NextCursor
+	}
+}
+
+// ResourcesByFormat returns all the rtype resources of a given format,
+// e.g. "svg" or "pdf". The filtering is done server-side with the
+// search API.
+func (s *Service) ResourcesByFormat(format string, rtype ResourceType) ([]*Resource, error) {
+	format = NormalizeFormat(format)
+	if format == "" {
+		return nil, errors.New("empty format")
+	}
+	return s.Search(fmt.Sprintf("resource_type:%s AND format=%q", resourceTypeName(rtype), format))
+}
+
+// NormalizeFormat returns format lowercased, without any leading dot,
+// as reported by Cloudinary.
+func NormalizeFormat(format string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(format), "."))
+}
@@ -0,0 +1,90 @@
+// Copyright 2013 Mathias Monnerville and Anthony Baillard.
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+package cloudinary
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestSearch(t *testing.T) {
+	queries := make([]map[string]interface{}, 0)
+	s, ts := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/v1_1/cloudname/resources/search" {
+			t.Errorf("wrong request %s %s", r.Method, r.URL.Path)
+		}
+		if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+			t.Errorf("wrong content type %s", ct)
+		}
+		var q map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&q); err != nil {
+			t.Fatal(err)
+		}
+		queries = append(queries, q)
+		if q["next_cursor"] == nil {
+			fmt.Fprint(w, `{"total_count":2,"resources":[{"public_id":"a","created_at":"2017-10-29T06:49:05Z"}],"next_cursor":"c1"}`)
+			return
+		}
+		fmt.Fprint(w, `{"total_count":2,"resources":[{"public_id":"b","created_at":"2017-10-30T08:00:00Z"}]}`)
+	}))
+	defer ts.Close()
+
+	res, err := s.Search("tags=brand")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(queries) != 2 || queries[0]["expression"] != "tags=brand" || queries[1]["next_cursor"] != "c1" {
+		t.Errorf("wrong search queries %v", queries)
+	}
+	if len(res) != 2 || res[1].PublicId != "b" {
+		t.Fatalf("wrong search results %+v", res)
+	}
+	if exp := time.Date(2017, 10, 30, 8, 0, 0, 0, time.UTC); !res[1].CreatedAt.Equal(exp) {
+		t.Errorf("wrong creation date %v", res[1].CreatedAt)
+	}
+}
+
+func TestResourcesByFormat(t *testing.T) {
+	var expr interface{}
+	s, ts := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var q map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&q); err != nil {
+			t.Fatal(err)
+		}
+		expr = q["expression"]
+		fmt.Fprint(w, `{"resources":[{"public_id":"logo","format":"svg"}]}`)
+	}))
+	defer ts.Close()
+
+	res, err := s.ResourcesByFormat(".SVG", ImageType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expr != `resource_type:image AND format="svg"` {
+		t.Errorf("wrong search expression %v", expr)
+	}
+	if len(res) != 1 || res[0].Format != "svg" {
+		t.Errorf("wrong results %+v", res)
+	}
+	if _, err := s.ResourcesByFormat(" ", ImageType); err == nil {
+		t.Error("an empty format should be rejected")
+	}
+}
+
+func TestSearchQuote(t *testing.T) {
+	for v, exp := range map[string]string{
+		"photos/*":            `"photos/*"`,
+		`say "cheese"`:        `"say \"cheese\""`,
+		`a\b`:                 `"a\\b"`,
+		"caf\u00e9\u00a0menu": "\"caf\u00e9\u00a0menu\"",
+	} {
+		if q := searchQuote(v); q != exp {
+			t.Errorf("%s: expect %s, got %s", v, exp, q)
+		}
+	}
+}
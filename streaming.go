@@ -0,0 +1,117 @@
+// Copyright 2013 Mathias Monnerville and Anthony Baillard.
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cloudinary
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+const (
+	pathStreamingProfiles = "/streaming_profiles"
+)
+
+// StreamingProfile defines the representations of a video delivered
+// with adaptive bitrate streaming (HLS or DASH).
+type StreamingProfile struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"display_name"`
+	Predefined  bool   `json:"predefined"` // Built-in profiles can't be deleted
+}
+
+type streamingProfileList struct {
+	Data []StreamingProfile `json:"data"`
+}
+
+// StreamingProfiles returns the streaming profiles of the account,
+// predefined ones included.
+func (s *Service) StreamingProfiles() ([]StreamingProfile, error) {
+	resp, err := s.client.Get(fmt.Sprintf("%s%s", s.adminURL(), pathStreamingProfiles))
+	if err != nil {
+		return nil, err
+	}
+	pl := new(streamingProfileList)
+	if err := decodeResponse(resp, pl); err != nil {
+		return nil, err
+	}
+	return pl.Data, nil
+}
+
+// CreateStreamingProfile creates the name streaming profile. There is
+// one representation per transformation, from the highest to the
+// lowest quality, e.g. "w_1280,h_720,c_limit,vc_h264:main:31,br_3500k".
+func (s *Service) CreateStreamingProfile(name, displayName string, transformations []string) error {
+	if err := s.writable(); err != nil {
+		return err
+	}
+	if name == "" {
+		return errors.New("missing streaming profile name")
+	}
+	if len(transformations) == 0 {
+		return errors.New("a streaming profile needs at least one representation")
+	}
+	type representation struct {
+		Transformation string `json:"transformation"`
+	}
+	reps := make([]representation, 0, len(transformations))
+	for _, tr := range transformations {
+		t, err := ParseTransformation(tr)
+		if err != nil {
+			return fmt.Errorf("representation %q: %s", tr, err)
+		}
+		if t.String() == "" {
+			return errors.New("empty representation")
+		}
+		reps = append(reps, representation{t.String()})
+	}
+	encoded, err := json.Marshal(reps)
+	if err != nil {
+		return err
+	}
+	if s.simulate {
+		return nil
+	}
+	data := url.Values{
+		"name":            []string{name},
+		"representations": []string{string(encoded)},
+	}
+	if displayName != "" {
+		data.Set("display_name", displayName)
+	}
+	resp, err := s.client.PostForm(fmt.Sprintf("%s%s", s.adminURL(), pathStreamingProfiles), data)
+	if err != nil {
+		return err
+	}
+	var m map[string]interface{}
+	return decodeResponse(resp, &m)
+}
+
+// DeleteStreamingProfile deletes the name streaming profile. Deleting a
+// predefined profile restores its default settings.
+func (s *Service) DeleteStreamingProfile(name string) error {
+	if err := s.writable(); err != nil {
+		return err
+	}
+	if name == "" {
+		return errors.New("missing streaming profile name")
+	}
+	if s.simulate {
+		return nil
+	}
+	req, err := http.NewRequest("DELETE", fmt.Sprintf("%s%s/%s", s.adminURL(), pathStreamingProfiles, url.PathEscape(name)), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	var m map[string]interface{}
+	return decodeResponse(resp, &m)
+}
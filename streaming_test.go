@@ -0,0 +1,106 @@
+// Copyright 2013 Mathias Monnerville and Anthony Baillard.
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+package cloudinary
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestStreamingProfiles(t *testing.T) {
+	s, ts := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" || r.URL.Path != "/v1_1/cloudname/streaming_profiles" {
+			t.Errorf("wrong request %s %s", r.Method, r.URL.Path)
+		}
+		fmt.Fprint(w, `{"data":[{"name":"hd","display_name":"HD","predefined":true},{"name":"mobile","display_name":"Mobile","predefined":false}]}`)
+	}))
+	defer ts.Close()
+
+	profiles, err := s.StreamingProfiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(profiles) != 2 || !profiles[0].Predefined || profiles[1].Name != "mobile" || profiles[1].DisplayName != "Mobile" {
+		t.Errorf("wrong profiles %+v", profiles)
+	}
+}
+
+func TestCreateStreamingProfile(t *testing.T) {
+	var method, path, name, displayName, reps string
+	s, ts := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method, path = r.Method, r.URL.Path
+		name, displayName, reps = r.FormValue("name"), r.FormValue("display_name"), r.FormValue("representations")
+		fmt.Fprint(w, `{"message":"created"}`)
+	}))
+	defer ts.Close()
+
+	tr := []string{"w_1280,h_720,c_limit,vc_h264:main:31,br_3500k", "w_640,h_360,c_limit,vc_h264:baseline:30,br_800k"}
+	if err := s.CreateStreamingProfile("mobile", "Mobile", tr); err != nil {
+		t.Fatal(err)
+	}
+	if method != "POST" || path != "/v1_1/cloudname/streaming_profiles" {
+		t.Errorf("wrong create request %s %s", method, path)
+	}
+	exp := `[{"transformation":"w_1280,h_720,c_limit,vc_h264:main:31,br_3500k"},{"transformation":"w_640,h_360,c_limit,vc_h264:baseline:30,br_800k"}]`
+	if name != "mobile" || displayName != "Mobile" || reps != exp {
+		t.Errorf("wrong create parameters name=%s display_name=%s representations=%s", name, displayName, reps)
+	}
+	if err := s.CreateStreamingProfile("", "", tr); err == nil {
+		t.Error("empty name should be rejected")
+	}
+	for _, bad := range [][]string{nil, {""}, {"w_300//c_fill"}} {
+		if err := s.CreateStreamingProfile("mobile", "", bad); err == nil {
+			t.Errorf("representations %q should be rejected", bad)
+		}
+	}
+
+	method = ""
+	s.Simulate(true)
+	if err := s.CreateStreamingProfile("desktop", "", tr); err != nil {
+		t.Fatal(err)
+	}
+	if method != "" {
+		t.Errorf("no request should be sent in simulation mode, got %s %s", method, path)
+	}
+	s.Simulate(false)
+}
+
+func TestDeleteStreamingProfile(t *testing.T) {
+	var method, path string
+	s, ts := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method, path = r.Method, r.URL.Path
+		if path == "/v1_1/cloudname/streaming_profiles/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"error":{"message":"Streaming profile not found"}}`)
+			return
+		}
+		fmt.Fprint(w, `{"message":"deleted"}`)
+	}))
+	defer ts.Close()
+
+	if err := s.DeleteStreamingProfile("mobile"); err != nil {
+		t.Fatal(err)
+	}
+	if method != "DELETE" || path != "/v1_1/cloudname/streaming_profiles/mobile" {
+		t.Errorf("wrong delete request %s %s", method, path)
+	}
+	if err := s.DeleteStreamingProfile("missing"); !isNotFound(err) {
+		t.Errorf("expect a not found error, got %v", err)
+	}
+	if err := s.DeleteStreamingProfile(""); err == nil {
+		t.Error("empty name should be rejected")
+	}
+
+	method = ""
+	s.Simulate(true)
+	if err := s.DeleteStreamingProfile("mobile"); err != nil {
+		t.Fatal(err)
+	}
+	if method != "" {
+		t.Errorf("no request should be sent in simulation mode, got %s %s", method, path)
+	}
+	s.Simulate(false)
+}
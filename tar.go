@@ -0,0 +1,162 @@
+// Copyright 2013 Mathias Monnerville and Anthony Baillard.
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cloudinary
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// UploadTar uploads the dir directory as a single gzipped tar archive,
+// stored as a raw resource named publicID (used as is, extension
+// included). The archive is streamed to Cloudinary while being built,
+// no temporary file is used.
+func (s *Service) UploadTar(dir, publicID string) (*Resource, error) {
+	if err := s.writable(); err != nil {
+		return nil, err
+	}
+	fi, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !fi.IsDir() {
+		return nil, fmt.Errorf("%s: not a directory", dir)
+	}
+	publicID = s.caseID(strings.Trim(publicID, "/"))
+	if publicID == "" {
+		return nil, errors.New("missing public id")
+	}
+	if s.simulate {
+		return nil, nil
+	}
+	params := url.Values{
+		"public_id": []string{publicID},
+		"timestamp": []string{strconv.FormatInt(s.now().Unix(), 10)},
+	}
+	s.signParams(params)
+
+	pr, pw := io.Pipe()
+	w := multipart.NewWriter(pw)
+	written := make(chan int64, 1)
+	go func() {
+		n, err := writeTarForm(w, params, dir, filepath.Base(publicID))
+		written <- n
+		pw.CloseWithError(err)
+	}()
+	upURI := strings.Replace(s.uploadURI.String(), imageType, rawType, 1)
+	req, err := http.NewRequest("POST", upURI, pr)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	s.logger.Printf("Uploading: %s\n", dir)
+	resp, err := s.client.Do(req)
+	// Unblocks the archive writer if the body hasn't been read entirely
+	pr.Close()
+	size := <-written
+	if err != nil {
+		return nil, err
+	}
+	s.detailsCache.evict(publicID)
+	res := new(Resource)
+	if err := decodeResponse(resp, res); err != nil {
+		return nil, err
+	}
+	s.metrics.upload(size)
+	return res, nil
+}
+
+// writeTarForm writes the multipart form of a tar upload: the params
+// fields then the archive of dir as the file field. It returns the
+// size of the compressed archive.
+func writeTarForm(w *multipart.Writer, params url.Values, dir, name string) (int64, error) {
+	for k := range params {
+		if err := w.WriteField(k, params.Get(k)); err != nil {
+			return 0, err
+		}
+	}
+	fw, err := createFilePart(w, name, "application/gzip")
+	if err != nil {
+		return 0, err
+	}
+	cw := &countingWriter{w: fw}
+	if err := writeTarGz(cw, dir); err != nil {
+		return cw.n, err
+	}
+	return cw.n, w.Close()
+}
+
+// writeTarGz writes a gzipped tar archive of the dir directory to w.
+// Paths are relative to dir.
+func writeTarGz(w io.Writer, dir string) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil || rel == "." {
+			return err
+		}
+		var link string
+		if info.Mode()&os.ModeSymlink != 0 {
+			if link, err = os.Readlink(path); err != nil {
+				return err
+			}
+		}
+		hdr, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return err
+		}
+		hdr.Name = filepath.ToSlash(rel)
+		if info.IsDir() {
+			hdr.Name += "/"
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(tw, f)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// countingWriter counts the bytes written to w.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,102 @@
+// Copyright 2013 Mathias Monnerville and Anthony Baillard.
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+package cloudinary
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestUploadTar(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cloudinary")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	files := map[string]string{
+		"index.html":     "<html></html>",
+		"css/site.css":   "body {}",
+		"css/print.css":  "",
+		"img/a/logo.svg": "<svg/>",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got := make(map[string]string)
+	var path, publicID string
+	s, ts := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		mr, err := r.MultipartReader()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for {
+			part, err := mr.NextPart()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if part.FormName() == "public_id" {
+				b, _ := ioutil.ReadAll(part)
+				publicID = string(b)
+			}
+			if part.FormName() != "file" {
+				continue
+			}
+			gz, err := gzip.NewReader(part)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tr := tar.NewReader(gz)
+			for {
+				hdr, err := tr.Next()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				if hdr.Typeflag == tar.TypeReg {
+					b, _ := ioutil.ReadAll(tr)
+					got[hdr.Name] = string(b)
+				}
+			}
+		}
+		fmt.Fprint(w, `{"public_id":"backups/snapshot.tar.gz","resource_type":"raw","bytes":42}`)
+	}))
+	defer ts.Close()
+
+	res, err := s.UploadTar(dir, "/backups/snapshot.tar.gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != "/v1_1/cloudname/raw/upload/" || publicID != "backups/snapshot.tar.gz" {
+		t.Errorf("wrong upload %s with public id %q", path, publicID)
+	}
+	if !reflect.DeepEqual(got, files) {
+		t.Errorf("wrong archive content. Expect %q, got %q", files, got)
+	}
+	if res.PublicId != "backups/snapshot.tar.gz" || s.Metrics().Uploads != 1 {
+		t.Errorf("wrong upload result %+v", res)
+	}
+	if _, err := s.UploadTar(filepath.Join(dir, "index.html"), "x.tar.gz"); err == nil {
+		t.Error("only directories should be accepted")
+	}
+}
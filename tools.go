@@ -7,9 +7,16 @@ package cloudinary
 
 import (
 	"crypto/sha1"
+	"crypto/sha256"
 	"fmt"
+	"hash"
 	"io"
 	"io/ioutil"
+	"math/bits"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
 )
 
 // Returns SHA1 file checksum
@@ -22,3 +29,48 @@ func fileChecksum(path string) (string, error) {
 	io.WriteString(hash, string(data))
 	return fmt.Sprintf("%x", hash.Sum(nil)), nil
 }
+
+// Signature algorithms, see SetSignatureAlgorithm().
+const (
+	SignatureSHA1   = "sha1"
+	SignatureSHA256 = "sha256"
+)
+
+// apiSignature signs API parameters: parameters are sorted by name,
+// serialized as name=value pairs joined with '&' and the API secret is
+// appended before hashing with algo. The file, api_key, resource_type
+// and cloud_name parameters must not be part of params.
+func apiSignature(params url.Values, secret, algo string) string {
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, k+"="+strings.Join(params[k], ","))
+	}
+	var h hash.Hash
+	if algo == SignatureSHA256 {
+		h = sha256.New()
+	} else {
+		h = sha1.New()
+	}
+	io.WriteString(h, strings.Join(parts, "&")+secret)
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+// PhashDistance returns the Hamming distance between two perceptual
+// hashes as returned by Cloudinary (64 bits, hex encoded). The lower
+// the distance, the more similar the images.
+func PhashDistance(a, b string) (int, error) {
+	x, err := strconv.ParseUint(a, 16, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid phash %q", a)
+	}
+	y, err := strconv.ParseUint(b, 16, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid phash %q", b)
+	}
+	return bits.OnesCount64(x ^ y), nil
+}
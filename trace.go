@@ -0,0 +1,183 @@
+// Copyright 2013 Mathias Monnerville and Anthony Baillard.
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cloudinary
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// traceBodyLimit is the number of bytes of a response body traced.
+const traceBodyLimit = 4096
+
+// redacted replaces credentials and signatures in traces.
+const redacted = "[redacted]"
+
+// SetTrace writes a dump of every HTTP request and response to w, e.g.
+// os.Stderr, to diagnose signature or parameter errors: method, URL,
+// headers and form parameters of requests, status and body of
+// responses. The API secret, the Authorization header and signatures
+// are redacted; uploaded files are only traced by size. Use nil to
+// disable. Not safe to call while requests are in flight.
+func (s *Service) SetTrace(w io.Writer) {
+	s.tracer.w = w
+}
+
+// tracer writes the requests sent and responses received by a service.
+type tracer struct {
+	mu    sync.Mutex // Keeps the dumps of concurrent requests apart
+	w     io.Writer
+	creds func() (key, secret string)
+}
+
+// request traces req, without consuming its body.
+func (t *tracer) request(req *http.Request) {
+	var b strings.Builder
+	u := *req.URL
+	u.User = nil
+	u.RawQuery = redactValues(u.Query()).Encode()
+	fmt.Fprintf(&b, "> %s %s\n", req.Method, u.String())
+	names := make([]string, 0, len(req.Header))
+	for name := range req.Header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		value := strings.Join(req.Header[name], ", ")
+		if name == "Authorization" {
+			value = redacted
+		}
+		fmt.Fprintf(&b, "> %s: %s\n", name, value)
+	}
+	t.body(&b, req)
+	t.write(b.String())
+}
+
+// body traces the form parameters of req. Bodies which can't be read
+// again, e.g. streamed tar archives, are not traced.
+func (t *tracer) body(b *strings.Builder, req *http.Request) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return
+	}
+	if req.GetBody == nil {
+		b.WriteString("> (streamed body not traced)\n")
+		return
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		fmt.Fprintf(b, "> (body not traced: %s)\n", err)
+		return
+	}
+	defer body.Close()
+	mediaType, params, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	switch mediaType {
+	case "application/x-www-form-urlencoded":
+		data, err := ioutil.ReadAll(body)
+		if err != nil {
+			return
+		}
+		form, err := url.ParseQuery(string(data))
+		if err != nil {
+			fmt.Fprintf(b, "> (invalid form: %s)\n", err)
+			return
+		}
+		writeValues(b, redactValues(form))
+	case "multipart/form-data":
+		mr := multipart.NewReader(body, params["boundary"])
+		form := url.Values{}
+		var files []string
+		for {
+			p, err := mr.NextPart()
+			if err != nil {
+				break
+			}
+			data, _ := ioutil.ReadAll(p)
+			if p.FileName() != "" {
+				files = append(files, fmt.Sprintf("> %s=<%s, %d bytes>\n", p.FormName(), p.FileName(), len(data)))
+				continue
+			}
+			form.Add(p.FormName(), string(data))
+		}
+		writeValues(b, redactValues(form))
+		for _, f := range files {
+			b.WriteString(f)
+		}
+	default:
+		data, _ := ioutil.ReadAll(body)
+		fmt.Fprintf(b, "> %s\n", data)
+	}
+}
+
+// response traces resp, or err if the request failed. The traced part
+// of the body is put back so that it can still be read.
+func (t *tracer) response(req *http.Request, resp *http.Response, err error) {
+	if err != nil {
+		t.write(fmt.Sprintf("< %s %s: %s\n", req.Method, req.URL.Path, err))
+		return
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "< %s\n", resp.Status)
+	head, _ := ioutil.ReadAll(io.LimitReader(resp.Body, traceBodyLimit))
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(head), resp.Body), resp.Body}
+	if len(head) > 0 {
+		fmt.Fprintf(&b, "< %s", head)
+		if len(head) == traceBodyLimit {
+			b.WriteString("...")
+		}
+		b.WriteString("\n")
+	}
+	t.write(b.String())
+}
+
+// write writes a dump, with the API secret redacted wherever it
+// appears.
+func (t *tracer) write(dump string) {
+	if t.creds != nil {
+		if _, secret := t.creds(); secret != "" {
+			dump = strings.Replace(dump, secret, redacted, -1)
+		}
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	io.WriteString(t.w, dump)
+}
+
+// redactValues returns a copy of values with signatures and secrets
+// redacted.
+func redactValues(values url.Values) url.Values {
+	redactedValues := make(url.Values, len(values))
+	for k, v := range values {
+		if k == "signature" || k == "api_secret" {
+			v = []string{redacted}
+		}
+		redactedValues[k] = v
+	}
+	return redactedValues
+}
+
+// writeValues writes form parameters, sorted by name.
+func writeValues(b *strings.Builder, values url.Values) {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(b, "> %s=%s\n", k, strings.Join(values[k], ","))
+	}
+}
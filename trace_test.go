@@ -0,0 +1,63 @@
+// Copyright 2013 Mathias Monnerville and Anthony Baillard.
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+package cloudinary
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestTrace(t *testing.T) {
+	s, ts := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"public_id":"images/logo","version":1,"result":"ok"}`)
+	}))
+	defer ts.Close()
+	const secret = "t0p-s3cret"
+	if err := s.UpdateCredentials("key", secret); err != nil {
+		t.Fatal(err)
+	}
+	var trace bytes.Buffer
+	s.SetTrace(&trace)
+
+	if _, err := s.Upload("logo.png", strings.NewReader("png data"), "images/", false, ImageType); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.ResourceDetails("images/logo"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Delete("logo", "images/", ImageType, false); err != nil {
+		t.Fatal(err)
+	}
+	out := trace.String()
+	for _, exp := range []string{
+		"> POST https://api.cloudinary.com/v1_1/cloudname/image/upload/\n",
+		"> public_id=images/logo\n",
+		"> file=<logo.png, 8 bytes>\n",
+		"> GET https://api.cloudinary.com/v1_1/cloudname/resources/image/upload/images/logo?coordinates=true\n",
+		"> Authorization: [redacted]\n",
+		"> POST https://api.cloudinary.com/v1_1/cloudname/image/destroy/\n",
+		"> signature=[redacted]\n",
+		"< 200 OK\n< {\"public_id\":\"images/logo\"",
+	} {
+		if !strings.Contains(out, exp) {
+			t.Errorf("expect %q in the trace:\n%s", exp, out)
+		}
+	}
+	if strings.Contains(out, secret) {
+		t.Errorf("the secret should never be traced:\n%s", out)
+	}
+
+	s.SetTrace(nil)
+	trace.Reset()
+	if _, err := s.ResourceDetails("images/other"); err != nil {
+		t.Fatal(err)
+	}
+	if trace.Len() != 0 {
+		t.Errorf("expect no trace once disabled, got %s", trace.String())
+	}
+}
@@ -0,0 +1,56 @@
+// Copyright 2013 Mathias Monnerville and Anthony Baillard.
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cloudinary
+
+import (
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// trackingStore keeps track of the checksums of uploaded files, so that
+// unchanged files are not uploaded again. See UseDatabase().
+type trackingStore interface {
+	// Get returns the first entry found among ids, nil if none.
+	Get(ids ...string) (*uploadResponse, error)
+	// Put inserts or replaces the entry of info.Id.
+	Put(info *uploadResponse) error
+	// Delete removes the entry of id, if any.
+	Delete(id string) error
+}
+
+// mongoStore is a trackingStore backed by a MongoDB collection.
+type mongoStore struct {
+	col *mgo.Collection
+}
+
+func (m *mongoStore) Get(ids ...string) (*uploadResponse, error) {
+	or := make([]bson.M, len(ids))
+	for i, id := range ids {
+		or[i] = bson.M{"_id": id}
+	}
+	match := new(uploadResponse)
+	err := m.col.Find(bson.M{"$or": or}).One(match)
+	if err == mgo.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return match, nil
+}
+
+func (m *mongoStore) Put(info *uploadResponse) error {
+	_, err := m.col.UpsertId(info.Id, info)
+	return err
+}
+
+func (m *mongoStore) Delete(id string) error {
+	err := m.col.RemoveId(id)
+	if err == mgo.ErrNotFound {
+		return nil
+	}
+	return err
+}
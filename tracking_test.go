@@ -0,0 +1,104 @@
+// Copyright 2013 Mathias Monnerville and Anthony Baillard.
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+package cloudinary
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// memoryStore is a trackingStore counting its calls.
+type memoryStore struct {
+	entries          map[string]*uploadResponse
+	gets, puts, dels int
+}
+
+func (m *memoryStore) Get(ids ...string) (*uploadResponse, error) {
+	m.gets++
+	for _, id := range ids {
+		if e, ok := m.entries[id]; ok {
+			return e, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *memoryStore) Put(info *uploadResponse) error {
+	m.puts++
+	m.entries[info.Id] = info
+	return nil
+}
+
+func (m *memoryStore) Delete(id string) error {
+	m.dels++
+	delete(m.entries, id)
+	return nil
+}
+
+func TestSimulatedSyncDoesNotWrite(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sync")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for _, name := range []string{"same.png", "changed.png", "new.png"} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	same, err := fileChecksum(filepath.Join(dir, "same.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	uploads := 0
+	s, ts := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1_1/cloudname/image/destroy/" {
+			fmt.Fprint(w, `{"result":"ok"}`)
+			return
+		}
+		uploads++
+		fmt.Fprintf(w, `{"public_id":%q,"version":1,"resource_type":"image"}`, r.FormValue("public_id"))
+	}), WithLogger(log.New(ioutil.Discard, "", 0)))
+	defer ts.Close()
+	store := &memoryStore{entries: map[string]*uploadResponse{
+		"same":    {Id: "same", PublicId: "same", Checksum: same},
+		"changed": {Id: "changed", PublicId: "changed", Checksum: "outdated"},
+	}}
+	s.store = store
+
+	s.Simulate(true)
+	if _, err := s.UploadWithOptions(dir, nil, "", false, ImageType, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Delete("changed", "", ImageType, false); err != nil {
+		t.Fatal(err)
+	}
+	if store.gets != 3 {
+		t.Errorf("expect the 3 files to be looked up, got %d lookups", store.gets)
+	}
+	if store.puts != 0 || store.dels != 0 || uploads != 0 {
+		t.Errorf("a simulated sync should not write, got %d puts, %d deletes and %d uploads", store.puts, store.dels, uploads)
+	}
+
+	// The same sync for real: only the changed and new files are tracked
+	s.Simulate(false)
+	if _, err := s.UploadWithOptions(dir, nil, "", false, ImageType, nil); err != nil {
+		t.Fatal(err)
+	}
+	if store.puts != 2 || uploads != 2 || store.entries["changed"].Checksum == "outdated" || store.entries["new"] == nil {
+		t.Errorf("expect 2 tracked uploads, got %d puts, %d uploads, entries %v", store.puts, uploads, store.entries)
+	}
+	if _, err := s.Delete("changed", "", ImageType, false); err != nil {
+		t.Fatal(err)
+	}
+	if store.dels != 1 || store.entries["changed"] != nil {
+		t.Errorf("the deleted resource should not be tracked anymore")
+	}
+}
@@ -0,0 +1,207 @@
+// Copyright 2013 Mathias Monnerville and Anthony Baillard.
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cloudinary
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	condIf   = "if_"
+	condElse = "if_else"
+	condEnd  = "if_end"
+)
+
+// Transformation builds a chain of transformation components, applied
+// in order on delivery. Each component is a list of parameters such as
+// "c_fill,w_300". Conditional blocks, opened with If() and closed with
+// EndIf(), only apply when a condition on the resource is met:
+//
+//	t := new(Transformation).If("w_gt_1000", "c_scale", "w_1000").EndIf()
+//	opts := &URLOptions{Transformation: t.String()}
+//
+// Use Validate() to check that conditional blocks are well formed.
+type Transformation struct {
+	components [][]string
+}
+
+// ParseTransformation parses a transformation string such as
+// "if_w_gt_1000,c_scale,w_1000/if_end" and validates it, see Validate.
+func ParseTransformation(s string) (*Transformation, error) {
+	t := new(Transformation)
+	if s = strings.Trim(s, "/"); s == "" {
+		return t, nil
+	}
+	for _, c := range strings.Split(s, "/") {
+		if c == "" {
+			return nil, errors.New("empty transformation component")
+		}
+		t.components = append(t.components, strings.Split(c, ","))
+	}
+	return t, t.Validate()
+}
+
+// Chain appends a component made of params, e.g. "c_fill", "w_300".
+// Nothing is appended if params is empty.
+func (t *Transformation) Chain(params ...string) *Transformation {
+	if len(params) > 0 {
+		t.components = append(t.components, params)
+	}
+	return t
+}
+
+// If opens a conditional block: the following components only apply
+// if condition, e.g. "w_gt_1000" or "ar_lt_1.0", is met. params, if
+// any, are the first ones applied by the block.
+func (t *Transformation) If(condition string, params ...string) *Transformation {
+	return t.Chain(append([]string{condIf + condition}, params...)...)
+}
+
+// Else starts the components applied if the condition of the current
+// block is not met.
+func (t *Transformation) Else(params ...string) *Transformation {
+	return t.Chain(append([]string{condElse}, params...)...)
+}
+
+// EndIf closes the current conditional block.
+func (t *Transformation) EndIf() *Transformation {
+	return t.Chain(condEnd)
+}
+
+// Resize appends a component resizing with the crop mode, e.g. "fill"
+// or "scale", to width pixels at the dpr device pixel ratio, e.g.
+// "2.0". For responsive delivery, width and dpr can be "auto": they
+// are then set by the browser Client Hints. Empty values are omitted.
+func (t *Transformation) Resize(crop, width, dpr string) *Transformation {
+	var params []string
+	for _, p := range [][2]string{{"c_", crop}, {"w_", width}, {"dpr_", dpr}} {
+		if p[1] != "" {
+			params = append(params, p[0]+p[1])
+		}
+	}
+	return t.Chain(params...)
+}
+
+// Validate checks that every conditional block is closed, and that
+// else and end markers belong to a block. Conditional blocks can't be
+// nested. It also checks that parameter keys, crop modes and gravities
+// are known, that automatic widths come with a crop mode and that
+// device pixel ratios are valid.
+func (t *Transformation) Validate() error {
+	open, hasElse := false, false
+	for _, c := range t.components {
+		if err := validateParams(c); err != nil {
+			return err
+		}
+		switch cond := c[0]; {
+		case cond == condEnd:
+			if !open {
+				return errors.New("if_end without if_")
+			}
+			open = false
+		case cond == condElse:
+			if !open || hasElse {
+				return errors.New("if_else without if_")
+			}
+			hasElse = true
+		case strings.HasPrefix(cond, condIf):
+			if open {
+				return errors.New("nested conditions are not supported")
+			}
+			if cond == condIf {
+				return errors.New("if_ without condition")
+			}
+			open, hasElse = true, false
+		}
+	}
+	if open {
+		return errors.New("if_ without if_end")
+	}
+	return nil
+}
+
+// validateParams checks the params of a component.
+func validateParams(params []string) error {
+	crop, autoWidth := false, false
+	for _, p := range params {
+		if err := validateParam(p); err != nil {
+			return err
+		}
+		switch {
+		case strings.HasPrefix(p, "c_"):
+			crop = true
+		case p == "w_auto" || strings.HasPrefix(p, "w_auto:"):
+			autoWidth = true
+		case strings.HasPrefix(p, "dpr_"):
+			v := strings.TrimPrefix(p, "dpr_")
+			if f, err := strconv.ParseFloat(v, 64); v != "auto" && (err != nil || f <= 0) {
+				return fmt.Errorf("invalid device pixel ratio %q, expect auto or a positive number", v)
+			}
+		}
+	}
+	if autoWidth && !crop {
+		return errors.New("w_auto requires a crop mode, e.g. c_scale")
+	}
+	return nil
+}
+
+var (
+	// Known parameter keys, e.g. "w" for "w_300"
+	transformationKeys = setOf("a", "ac", "af", "ar", "b", "bo", "br", "c", "co", "cs", "d", "dl",
+		"dn", "dpr", "du", "e", "eo", "f", "fl", "fn", "fps", "g", "h", "if", "ki", "l", "o", "p",
+		"pg", "q", "r", "so", "sp", "t", "u", "vc", "vs", "w", "x", "y", "z")
+	cropModes = setOf("scale", "fit", "limit", "mfit", "fill", "lfill", "pad", "lpad", "mpad",
+		"fill_pad", "crop", "thumb", "imagga_crop", "imagga_scale", "auto")
+	// Gravities, without the focus options following a colon, as in
+	// "auto:faces"
+	gravities = setOf("north_west", "north", "north_east", "west", "center", "east", "south_west",
+		"south", "south_east", "xy_center", "face", "faces", "body", "auto", "custom", "liquid",
+		"ocr_text", "adv_face", "adv_faces", "adv_eyes")
+)
+
+func setOf(values ...string) map[string]bool {
+	m := make(map[string]bool, len(values))
+	for _, v := range values {
+		m[v] = true
+	}
+	return m
+}
+
+// validateParam checks that p is a key_value parameter with a known
+// key, or a user-defined variable such as "$width_300". The values of
+// crop modes and gravities are also checked.
+func validateParam(p string) error {
+	i := strings.Index(p, "_")
+	if i <= 0 {
+		return fmt.Errorf("invalid parameter %q, expect key_value", p)
+	}
+	key, value := p[:i], p[i+1:]
+	switch {
+	case key == "if" || strings.HasPrefix(key, "$"):
+		// Conditions are checked by Validate
+	case !transformationKeys[key]:
+		return fmt.Errorf("unknown parameter %q in %q", key, p)
+	case value == "":
+		return fmt.Errorf("missing value in %q", p)
+	case key == "c" && !cropModes[value]:
+		return fmt.Errorf("unknown crop mode %q in %q", value, p)
+	case key == "g" && !gravities[strings.SplitN(value, ":", 2)[0]]:
+		return fmt.Errorf("unknown gravity %q in %q", value, p)
+	}
+	return nil
+}
+
+// String returns the transformation as used in delivery URLs.
+func (t *Transformation) String() string {
+	c := make([]string, len(t.components))
+	for i, params := range t.components {
+		c[i] = strings.Join(params, ",")
+	}
+	return strings.Join(c, "/")
+}
@@ -0,0 +1,125 @@
+// Copyright 2013 Mathias Monnerville and Anthony Baillard.
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+package cloudinary
+
+import (
+	"crypto/sha1"
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func TestTransformationConditions(t *testing.T) {
+	tr := new(Transformation).
+		Chain("f_auto").
+		If("w_gt_1000", "c_scale", "w_1000").
+		Chain("e_sharpen").
+		Else("c_pad", "w_500").
+		EndIf().
+		Chain("q_auto")
+	if err := tr.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	exp := "f_auto/if_w_gt_1000,c_scale,w_1000/e_sharpen/if_else,c_pad,w_500/if_end/q_auto"
+	if s := tr.String(); s != exp {
+		t.Errorf("expect %s, got %s", exp, s)
+	}
+	parsed, err := ParseTransformation(exp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.String() != exp {
+		t.Errorf("parsed transformation should serialize back to %s, got %s", exp, parsed)
+	}
+}
+
+func TestTransformationValidate(t *testing.T) {
+	for _, s := range []string{
+		"if_w_gt_1000,c_scale,w_1000",
+		"c_scale,w_1000/if_end",
+		"if_else/if_end",
+		"if_w_gt_1000/if_ar_lt_1.0/if_end/if_end",
+		"if_w_gt_1000/if_else/if_else/if_end",
+		"if_/if_end",
+		"w_300//c_fill",
+	} {
+		if _, err := ParseTransformation(s); err == nil {
+			t.Errorf("%s should be rejected", s)
+		}
+	}
+	for _, s := range []string{"", "w_300,c_fill", "if_w_gt_1000/c_scale,w_1000/if_end/if_h_gt_500/if_else/if_end"} {
+		if _, err := ParseTransformation(s); err != nil {
+			t.Errorf("%s: %s", s, err)
+		}
+	}
+}
+
+func TestTransformationParams(t *testing.T) {
+	for _, s := range []string{
+		"w_300,c_fill,g_face",
+		"c_thumb,g_auto:faces,w_200,h_200/e_sharpen:100/f_auto,q_auto",
+		"$width_300/c_fill_pad,g_north_west,w_$width",
+		"vc_h264:main:31,br_3500k",
+		"l_logo,g_south_east,x_10,y_10,o_50/fl_layer_apply",
+	} {
+		if _, err := ParseTransformation(s); err != nil {
+			t.Errorf("%s: %s", s, err)
+		}
+	}
+	for s, msg := range map[string]string{
+		"w_300,c_fil":        `unknown crop mode "fil" in "c_fil"`,
+		"c_fill,g_fcae":      `unknown gravity "fcae" in "g_fcae"`,
+		"c_fill,g_auto/zz_1": `unknown parameter "zz" in "zz_1"`,
+		"w300":               `invalid parameter "w300", expect key_value`,
+		"c_scale,w_":         `missing value in "w_"`,
+		"_300":               `invalid parameter "_300", expect key_value`,
+	} {
+		_, err := ParseTransformation(s)
+		if err == nil || err.Error() != msg {
+			t.Errorf("%s: expect error %q, got %v", s, msg, err)
+		}
+	}
+}
+
+func TestTransformationResize(t *testing.T) {
+	for _, c := range []struct {
+		crop, width, dpr, exp string
+	}{
+		{"scale", "auto", "auto", "c_scale,w_auto,dpr_auto"},
+		{"fill", "300", "2.0", "c_fill,w_300,dpr_2.0"},
+		{"", "", "auto", "dpr_auto"},
+		{"limit", "auto:100", "", "c_limit,w_auto:100"},
+	} {
+		tr := new(Transformation).Resize(c.crop, c.width, c.dpr)
+		if err := tr.Validate(); err != nil {
+			t.Errorf("%s: %s", c.exp, err)
+		}
+		if s := tr.String(); s != c.exp {
+			t.Errorf("expect %s, got %s", c.exp, s)
+		}
+	}
+	for _, s := range []string{"w_auto", "w_auto,dpr_auto", "c_fill/w_auto:100", "dpr_0", "dpr_high"} {
+		if _, err := ParseTransformation(s); err == nil {
+			t.Errorf("%s should be rejected", s)
+		}
+	}
+	if err := new(Transformation).Resize("", "auto", "").Validate(); err == nil || !strings.Contains(err.Error(), "crop mode") {
+		t.Errorf("w_auto without crop mode should be rejected, got %v", err)
+	}
+}
+
+func TestSignedConditionalURL(t *testing.T) {
+	s, err := NewService("cloudname", "key", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := new(Transformation).If("w_gt_1000", "c_scale", "w_1000").EndIf().String()
+	sum := sha1.Sum([]byte("if_w_gt_1000,c_scale,w_1000/if_end/sample" + "secret"))
+	sig := "s--" + base64.URLEncoding.EncodeToString(sum[:])[:8] + "--"
+	exp := "https://res.cloudinary.com/cloudname/image/upload/" + sig + "/if_w_gt_1000,c_scale,w_1000/if_end/sample"
+	if u := s.SignedURL("sample", ImageType, &URLOptions{Transformation: tr}); u != exp {
+		t.Errorf("expect %s, got %s", exp, u)
+	}
+}
@@ -0,0 +1,133 @@
+// Copyright 2013 Mathias Monnerville and Anthony Baillard.
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cloudinary
+
+import (
+	"errors"
+	"net/http"
+	"time"
+)
+
+// ClientVersion is the version of this package, sent in the User-Agent
+// header of all requests.
+const ClientVersion = "1.0.0"
+
+// DefaultUserAgent is the User-Agent header of all requests, see
+// SetUserAgent.
+const DefaultUserAgent = "cloudinary-go-cli/" + ClientVersion
+
+// ErrOffline is the error of the requests sent in offline mode, see
+// SetOffline.
+var ErrOffline = errors.New("offline mode, the Cloudinary service can't be contacted")
+
+// TransportOptions tunes the connections to the Cloudinary service.
+type TransportOptions struct {
+	// MaxIdleConnsPerHost is the number of idle connections kept open
+	// for reuse. Raise it when sending many requests concurrently.
+	MaxIdleConnsPerHost int
+	// DisableKeepAlives opens a new connection for every request.
+	DisableKeepAlives bool
+	// ForceHTTP2 attempts HTTP/2, so that requests are multiplexed on
+	// a single connection when the server supports it.
+	ForceHTTP2 bool
+}
+
+// DefaultTransportOptions are used unless WithHTTPClient,
+// WithTransportOptions, SetHTTPClient or SetTransportOptions is used.
+// They are tuned for batch operations, which send many requests in a
+// row to the same host.
+var DefaultTransportOptions = TransportOptions{
+	MaxIdleConnsPerHost: 16,
+	ForceHTTP2:          true,
+}
+
+// newHTTPClient returns an HTTP client using a transport tuned with o.
+// Proxy, dial and TLS settings are those of http.DefaultTransport.
+func newHTTPClient(o TransportOptions, timeout time.Duration) *http.Client {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	if o.MaxIdleConnsPerHost > 0 {
+		t.MaxIdleConnsPerHost = o.MaxIdleConnsPerHost
+		if t.MaxIdleConns > 0 && t.MaxIdleConns < o.MaxIdleConnsPerHost {
+			t.MaxIdleConns = o.MaxIdleConnsPerHost
+		}
+	}
+	t.DisableKeepAlives = o.DisableKeepAlives
+	t.ForceAttemptHTTP2 = o.ForceHTTP2
+	return &http.Client{Transport: t, Timeout: timeout}
+}
+
+// WithTransportOptions tunes the connections to the Cloudinary service.
+func WithTransportOptions(o TransportOptions) Option {
+	return func(s *Service) {
+		s.client = newHTTPClient(o, 0)
+	}
+}
+
+// SetHTTPClient sets the HTTP client used for all requests to the
+// Cloudinary service. See WithHTTPClient.
+func (s *Service) SetHTTPClient(c *http.Client) {
+	s.client = c
+	s.instrumentClient()
+}
+
+// SetOffline makes all requests to the Cloudinary service fail with
+// ErrOffline, so that only local operations such as DeliveryURL or
+// SignedURL can be used. This guarantees no request is sent, e.g. when
+// generating URLs at build time without network access.
+func (s *Service) SetOffline() {
+	s.SetHTTPClient(&http.Client{Transport: offlineTransport{}})
+}
+
+type offlineTransport struct{}
+
+func (offlineTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	return nil, ErrOffline
+}
+
+// SetTransportOptions replaces the HTTP client with one tuned with o.
+// The timeout set with WithTimeout, if any, is kept.
+func (s *Service) SetTransportOptions(o TransportOptions) {
+	s.client = newHTTPClient(o, s.timeout)
+	s.instrumentClient()
+}
+
+// SetUserAgent appends ua, e.g. the name and version of the embedding
+// application, to DefaultUserAgent in the User-Agent header of all
+// requests. An empty ua restores DefaultUserAgent. Not safe to call
+// while requests are in flight.
+func (s *Service) SetUserAgent(ua string) {
+	s.ident.userAgent = DefaultUserAgent
+	if ua != "" {
+		s.ident.userAgent += " " + ua
+	}
+}
+
+// SetRequestID makes all requests carry an X-Request-Id header set to
+// the value returned by f, e.g. a trace id, so that they can be found
+// when contacting the Cloudinary support. No header is sent when f is
+// nil or returns an empty string. Not safe to call while requests are
+// in flight.
+func (s *Service) SetRequestID(f func() string) {
+	s.ident.requestID = f
+}
+
+// identity holds the headers identifying the requests of a service.
+type identity struct {
+	userAgent string
+	requestID func() string
+}
+
+// identify returns a copy of req with the identification headers set.
+func (id *identity) identify(req *http.Request) *http.Request {
+	req = req.Clone(req.Context())
+	req.Header.Set("User-Agent", id.userAgent)
+	if id.requestID != nil {
+		if rid := id.requestID(); rid != "" {
+			req.Header.Set("X-Request-Id", rid)
+		}
+	}
+	return req
+}
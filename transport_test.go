@@ -0,0 +1,152 @@
+// Copyright 2013 Mathias Monnerville and Anthony Baillard.
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+package cloudinary
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// baseTransport returns the transport of the service HTTP client.
+func baseTransport(s *Service) *http.Transport {
+	return s.client.Transport.(*metricsTransport).base.(*http.Transport)
+}
+
+// countConns runs n pings against a TLS test server with a service
+// tuned with o and returns the number of connections opened.
+func countConns(t *testing.T, o TransportOptions, n int) int {
+	var mu sync.Mutex
+	conns := 0
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"status":"ok"}`)
+	}))
+	ts.Config.ConnState = func(c net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			mu.Lock()
+			conns++
+			mu.Unlock()
+		}
+	}
+	ts.StartTLS()
+	defer ts.Close()
+
+	s, err := NewService("cloudname", "key", "secret", WithTransportOptions(o))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Trust the test server certificate
+	baseTransport(s).TLSClientConfig = ts.Client().Transport.(*http.Transport).TLSClientConfig
+	if err := s.SetAPIHost(ts.Listener.Addr().String()); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < n; i++ {
+		if err := s.Ping(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	return conns
+}
+
+func TestTransportConnectionReuse(t *testing.T) {
+	if c := countConns(t, DefaultTransportOptions, 5); c != 1 {
+		t.Errorf("expect a single connection reused by sequential requests, got %d", c)
+	}
+	if c := countConns(t, TransportOptions{DisableKeepAlives: true}, 5); c != 5 {
+		t.Errorf("expect one connection per request without keep-alives, got %d", c)
+	}
+}
+
+func TestSetTransportOptions(t *testing.T) {
+	s, err := NewService("cloudname", "key", "secret", WithTimeout(42))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := baseTransport(s)
+	if tr.MaxIdleConnsPerHost != DefaultTransportOptions.MaxIdleConnsPerHost || !tr.ForceAttemptHTTP2 {
+		t.Errorf("default client should use DefaultTransportOptions, got %+v", tr)
+	}
+	s.SetTransportOptions(TransportOptions{MaxIdleConnsPerHost: 64, DisableKeepAlives: true})
+	tr = baseTransport(s)
+	if tr.MaxIdleConnsPerHost != 64 || !tr.DisableKeepAlives || tr.ForceAttemptHTTP2 {
+		t.Errorf("transport options not applied: %+v", tr)
+	}
+	if s.client.Timeout != 42 {
+		t.Errorf("timeout should be kept, got %v", s.client.Timeout)
+	}
+	tr = &http.Transport{}
+	s.SetHTTPClient(&http.Client{Transport: tr})
+	if baseTransport(s) != tr {
+		t.Error("SetHTTPClient should replace the client")
+	}
+}
+
+func TestUserAgent(t *testing.T) {
+	var ua, rid string
+	s, ts := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ua, rid = r.Header.Get("User-Agent"), r.Header.Get("X-Request-Id")
+		fmt.Fprint(w, `{"status":"ok"}`)
+	}))
+	defer ts.Close()
+
+	if err := s.Ping(); err != nil {
+		t.Fatal(err)
+	}
+	if ua != "cloudinary-go-cli/"+ClientVersion || rid != "" {
+		t.Errorf("expect the default User-Agent and no request id, got %q and %q", ua, rid)
+	}
+
+	s.SetUserAgent("myapp/2.1")
+	n := 0
+	s.SetRequestID(func() string {
+		n++
+		return fmt.Sprintf("trace-%d", n)
+	})
+	for i := 1; i <= 2; i++ {
+		if err := s.Ping(); err != nil {
+			t.Fatal(err)
+		}
+		if ua != DefaultUserAgent+" myapp/2.1" {
+			t.Errorf("expect the application appended to the User-Agent, got %q", ua)
+		}
+		if exp := fmt.Sprintf("trace-%d", i); rid != exp {
+			t.Errorf("expect request id %q, got %q", exp, rid)
+		}
+	}
+
+	// Headers are kept when the HTTP client is replaced
+	s.SetHTTPClient(&http.Client{Transport: s.client.Transport.(*metricsTransport).base})
+	s.SetUserAgent("")
+	if err := s.Ping(); err != nil {
+		t.Fatal(err)
+	}
+	if ua != DefaultUserAgent || rid != "trace-3" {
+		t.Errorf("expect the default User-Agent and request id trace-3, got %q and %q", ua, rid)
+	}
+}
+
+func BenchmarkSequentialPings(b *testing.B) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"status":"ok"}`)
+	}))
+	defer ts.Close()
+	s, err := NewService("cloudname", "key", "secret")
+	if err != nil {
+		b.Fatal(err)
+	}
+	baseTransport(s).TLSClientConfig = ts.Client().Transport.(*http.Transport).TLSClientConfig
+	s.SetAPIHost(ts.Listener.Addr().String())
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := s.Ping(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
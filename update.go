@@ -0,0 +1,284 @@
+// Copyright 2013 Mathias Monnerville and Anthony Baillard.
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cloudinary
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Access modes of a resource.
+const (
+	AccessPublic        = "public"
+	AccessAuthenticated = "authenticated"
+)
+
+// UpdateOptions lists the changes applied to a resource by Update().
+// Zero values leave the matching metadata unchanged.
+type UpdateOptions struct {
+	// Tags replaces all the tags of the resource.
+	Tags []string
+	// AddTags and RemoveTags add and remove tags, keeping the others.
+	AddTags    []string
+	RemoveTags []string
+	// Context sets contextual metadata, as key-value pairs.
+	Context map[string]string
+	// AccessMode is either AccessPublic or AccessAuthenticated.
+	AccessMode string
+}
+
+func (o *UpdateOptions) empty() bool {
+	return o.Tags == nil && len(o.AddTags) == 0 && len(o.RemoveTags) == 0 &&
+		len(o.Context) == 0 && o.AccessMode == ""
+}
+
+func (o *UpdateOptions) validate() error {
+	if o.empty() {
+		return errors.New("nothing to update")
+	}
+	if o.Tags != nil && (len(o.AddTags) > 0 || len(o.RemoveTags) > 0) {
+		return errors.New("tags can't be both replaced and added or removed")
+	}
+	if o.AccessMode != "" && o.AccessMode != AccessPublic && o.AccessMode != AccessAuthenticated {
+		return fmt.Errorf("invalid access mode %q, must be %s or %s", o.AccessMode, AccessPublic, AccessAuthenticated)
+	}
+	for k := range o.Context {
+		if k == "" {
+			return errors.New("empty context key")
+		}
+	}
+	return nil
+}
+
+// validateTag checks a tag can be sent in a comma separated list.
+func validateTag(tag string) error {
+	if strings.TrimSpace(tag) == "" {
+		return errors.New("empty tag")
+	}
+	if strings.Contains(tag, ",") {
+		return fmt.Errorf("tag %q: must not contain a comma", tag)
+	}
+	return nil
+}
+
+// mergeTags returns tags with add appended and remove taken out.
+func mergeTags(tags, add, remove []string) []string {
+	drop := make(map[string]bool, len(remove))
+	for _, t := range remove {
+		drop[t] = true
+	}
+	seen := make(map[string]bool, len(tags)+len(add))
+	merged := make([]string, 0, len(tags)+len(add))
+	for _, t := range append(append([]string{}, tags...), add...) {
+		if drop[t] || seen[t] {
+			continue
+		}
+		seen[t] = true
+		merged = append(merged, t)
+	}
+	return merged
+}
+
+// contextEscaper escapes the context separators in keys and values.
+var contextEscaper = strings.NewReplacer(`=`, `\=`, `|`, `\|`)
+
+// encodeContext encodes key-value pairs the way Cloudinary expects
+// them, i.e. key1=value1|key2=value2, sorted by key. Structured
+// metadata fields are encoded the same way.
+func encodeContext(ctx map[string]string) string {
+	keys := make([]string, 0, len(ctx))
+	for k := range ctx {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		pairs[i] = contextEscaper.Replace(k) + "=" + contextEscaper.Replace(ctx[k])
+	}
+	return strings.Join(pairs, "|")
+}
+
+// validateMetadata checks the external ids of structured metadata
+// fields.
+func validateMetadata(fields map[string]string) error {
+	for id := range fields {
+		if strings.TrimSpace(id) == "" {
+			return errors.New("empty metadata field id")
+		}
+	}
+	return nil
+}
+
+// UpdateMetadata sets the structured metadata fields of the publicId
+// resource, by external id, using the upload API. Other fields are
+// left unchanged; an empty value clears a field. Nothing is sent in
+// simulation mode.
+func (s *Service) UpdateMetadata(publicId string, fields map[string]string, rtype ResourceType) error {
+	if err := s.writable(); err != nil {
+		return err
+	}
+	if len(fields) == 0 {
+		return errors.New("no metadata field to update")
+	}
+	if err := validateMetadata(fields); err != nil {
+		return err
+	}
+	if s.simulate {
+		return nil
+	}
+	key, secret := s.credentials()
+	params := url.Values{
+		"metadata":   []string{encodeContext(fields)},
+		"public_ids": []string{publicId},
+		"timestamp":  []string{strconv.FormatInt(s.now().Unix(), 10)},
+	}
+	data := url.Values{
+		"metadata":     params["metadata"],
+		"public_ids[]": params["public_ids"],
+		"timestamp":    params["timestamp"],
+		"signature":    []string{apiSignature(params, secret, s.signatureAlgo)},
+		"api_key":      []string{key},
+	}
+	resp, err := s.client.PostForm(fmt.Sprintf("%s/%s/%s/metadata", s.apiURL(), s.cloudName, resourceTypeName(rtype)), data)
+	if err != nil {
+		return err
+	}
+	s.detailsCache.evict(publicId)
+	var m map[string]interface{}
+	return decodeResponse(resp, &m)
+}
+
+// Update changes the tags, contextual metadata and access mode of a
+// resource in a single admin API call, and returns its updated details.
+// Adding or removing tags requires fetching the current tags first. In
+// simulation mode, nothing is sent and the details are nil.
+func (s *Service) Update(publicId string, opts UpdateOptions, rtype ResourceType) (*ResourceDetails, error) {
+	if err := s.writable(); err != nil {
+		return nil, err
+	}
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	tags := opts.Tags
+	if len(opts.AddTags) > 0 || len(opts.RemoveTags) > 0 {
+		cur, err := s.doGetResourceDetails(publicId, rtype, nil)
+		if err != nil {
+			return nil, err
+		}
+		tags = mergeTags(cur.Tags, opts.AddTags, opts.RemoveTags)
+	}
+	if s.simulate {
+		return nil, nil
+	}
+
+	data := url.Values{}
+	if tags != nil {
+		data.Set("tags", strings.Join(tags, ","))
+	}
+	if len(opts.Context) > 0 {
+		data.Set("context", encodeContext(opts.Context))
+	}
+	if opts.AccessMode != "" {
+		data.Set("access_mode", opts.AccessMode)
+	}
+	uri := fmt.Sprintf("%s/resources/%s/upload/%s", s.adminURL(), resourceTypeName(rtype), publicId)
+	resp, err := s.client.PostForm(uri, data)
+	if err != nil {
+		return nil, err
+	}
+	s.detailsCache.evict(publicId)
+	details := new(ResourceDetails)
+	if err := decodeResponse(resp, details); err != nil {
+		return nil, err
+	}
+	return details, nil
+}
+
+// AddTag adds tag to the resources matching publicIds, using the upload
+// API. Resources already tagged are left unchanged. Nothing is sent in
+// simulation mode.
+func (s *Service) AddTag(tag string, publicIds []string, rtype ResourceType) error {
+	if err := s.writable(); err != nil {
+		return err
+	}
+	if err := validateTag(tag); err != nil {
+		return err
+	}
+	if len(publicIds) == 0 {
+		return errors.New("no public id to tag")
+	}
+	if s.simulate {
+		return nil
+	}
+	key, secret := s.credentials()
+	params := url.Values{
+		"command":    []string{"add"},
+		"tag":        []string{tag},
+		"public_ids": publicIds,
+		"timestamp":  []string{strconv.FormatInt(s.now().Unix(), 10)},
+	}
+	data := url.Values{
+		"command":      params["command"],
+		"tag":          params["tag"],
+		"public_ids[]": publicIds,
+		"timestamp":    params["timestamp"],
+		"signature":    []string{apiSignature(params, secret, s.signatureAlgo)},
+		"api_key":      []string{key},
+	}
+	resp, err := s.client.PostForm(fmt.Sprintf("%s/%s/%s/tags", s.apiURL(), s.cloudName, resourceTypeName(rtype)), data)
+	if err != nil {
+		return err
+	}
+	s.detailsCache.evict(publicIds...)
+	var m map[string]interface{}
+	return decodeResponse(resp, &m)
+}
+
+// RemoveContext removes the key context entry of the resources
+// matching publicIds, or all their context entries if key is empty,
+// using the upload API. Nothing is sent in simulation mode.
+func (s *Service) RemoveContext(key string, publicIds []string, rtype ResourceType) error {
+	if err := s.writable(); err != nil {
+		return err
+	}
+	if len(publicIds) == 0 {
+		return errors.New("no public id to update")
+	}
+	if s.simulate {
+		return nil
+	}
+	k, secret := s.credentials()
+	params := url.Values{
+		"command":    []string{"remove_all"},
+		"public_ids": publicIds,
+		"timestamp":  []string{strconv.FormatInt(s.now().Unix(), 10)},
+	}
+	if key != "" {
+		params.Set("command", "remove")
+		params.Set("context", key)
+	}
+	data := url.Values{
+		"public_ids[]": publicIds,
+		"signature":    []string{apiSignature(params, secret, s.signatureAlgo)},
+		"api_key":      []string{k},
+	}
+	for _, p := range []string{"command", "context", "timestamp"} {
+		if v, ok := params[p]; ok {
+			data[p] = v
+		}
+	}
+	resp, err := s.client.PostForm(fmt.Sprintf("%s/%s/%s/context", s.apiURL(), s.cloudName, resourceTypeName(rtype)), data)
+	if err != nil {
+		return err
+	}
+	s.detailsCache.evict(publicIds...)
+	var m map[string]interface{}
+	return decodeResponse(resp, &m)
+}
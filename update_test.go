@@ -0,0 +1,184 @@
+// Copyright 2013 Mathias Monnerville and Anthony Baillard.
+// All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+package cloudinary
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestUpdateCombined(t *testing.T) {
+	var posts int
+	var form map[string][]string
+	s, ts := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1_1/cloudname/resources/image/upload/images/logo" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		if r.Method == "GET" {
+			fmt.Fprint(w, `{"public_id":"images/logo","tags":["brand","old"]}`)
+			return
+		}
+		posts++
+		if u, p, ok := r.BasicAuth(); !ok || u != "key" || p != "secret" {
+			t.Error("missing admin credentials")
+		}
+		r.ParseForm()
+		form = r.PostForm
+		fmt.Fprint(w, `{"public_id":"images/logo","tags":["brand","a"],"context":{"custom":{"alt":"Logo"}},"access_mode":"public"}`)
+	}))
+	defer ts.Close()
+
+	d, err := s.Update("images/logo", UpdateOptions{
+		AddTags:    []string{"a", "brand"},
+		RemoveTags: []string{"old"},
+		Context:    map[string]string{"alt": "Logo", "caption": "a=b|c"},
+		AccessMode: AccessPublic,
+	}, ImageType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if posts != 1 {
+		t.Fatalf("expect a single update request, got %d", posts)
+	}
+	exp := map[string][]string{
+		"tags":        {"brand,a"},
+		"context":     {`alt=Logo|caption=a\=b\|c`},
+		"access_mode": {"public"},
+	}
+	if !reflect.DeepEqual(form, exp) {
+		t.Errorf("wrong update params. Expect %v, got %v", exp, form)
+	}
+	if d.AccessMode != "public" || d.Context == nil || d.Context.Custom["alt"] != "Logo" {
+		t.Errorf("wrong updated details: %+v", d)
+	}
+
+	posts = 0
+	s.Simulate(true)
+	if d, err := s.Update("images/logo", UpdateOptions{AddTags: []string{"a"}}, ImageType); err != nil || d != nil {
+		t.Fatalf("expect no details in simulation mode, got %+v (%v)", d, err)
+	}
+	if posts != 0 {
+		t.Errorf("no update should be sent in simulation mode, got %d", posts)
+	}
+	s.Simulate(false)
+}
+
+func TestUpdateOptionsValidate(t *testing.T) {
+	bad := []UpdateOptions{
+		{},
+		{Tags: []string{"a"}, AddTags: []string{"b"}},
+		{AccessMode: "private"},
+		{Context: map[string]string{"": "v"}},
+	}
+	for _, o := range bad {
+		if err := o.validate(); err == nil {
+			t.Errorf("%+v should be rejected", o)
+		}
+	}
+	if err := (&UpdateOptions{Tags: []string{}}).validate(); err != nil {
+		t.Errorf("clearing tags should be allowed: %v", err)
+	}
+}
+
+func TestRemoveContext(t *testing.T) {
+	var path string
+	var form url.Values
+	s, ts := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		r.ParseForm()
+		form = r.PostForm
+		fmt.Fprint(w, `{"public_ids":["a","b"]}`)
+	}))
+	defer ts.Close()
+
+	if err := s.RemoveContext("alt", []string{"a", "b"}, ImageType); err != nil {
+		t.Fatal(err)
+	}
+	if path != "/v1_1/cloudname/image/context" {
+		t.Errorf("wrong path %s", path)
+	}
+	if form.Get("command") != "remove" || form.Get("context") != "alt" || !reflect.DeepEqual(form["public_ids[]"], []string{"a", "b"}) {
+		t.Errorf("wrong remove params %v", form)
+	}
+	signed := url.Values{"command": {"remove"}, "context": {"alt"}, "public_ids": {"a", "b"}, "timestamp": form["timestamp"]}
+	if form.Get("signature") != apiSignature(signed, "secret", SignatureSHA1) {
+		t.Error("invalid signature")
+	}
+
+	if err := s.RemoveContext("", []string{"a"}, RawType); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := form["context"]; ok || form.Get("command") != "remove_all" || path != "/v1_1/cloudname/raw/context" {
+		t.Errorf("wrong remove all request %s %v", path, form)
+	}
+	if err := s.RemoveContext("alt", nil, ImageType); err == nil {
+		t.Error("should fail without public ids")
+	}
+
+	path = ""
+	s.Simulate(true)
+	if err := s.RemoveContext("alt", []string{"a"}, ImageType); err != nil {
+		t.Fatal(err)
+	}
+	if path != "" {
+		t.Errorf("no request should be sent in simulation mode, got %s", path)
+	}
+	s.Simulate(false)
+}
+
+func TestUploadMetadata(t *testing.T) {
+	o := &UploadOptions{Metadata: map[string]string{"sku": "A|1", "color_id": "red", "note": "x=y"}}
+	if err := o.validate(); err != nil {
+		t.Fatal(err)
+	}
+	if m, exp := o.params().Get("metadata"), `color_id=red|note=x\=y|sku=A\|1`; m != exp {
+		t.Errorf("expect metadata %s, got %s", exp, m)
+	}
+	if err := (&UploadOptions{Metadata: map[string]string{" ": "v"}}).validate(); err == nil {
+		t.Error("empty field ids should be rejected")
+	}
+}
+
+func TestUpdateMetadata(t *testing.T) {
+	var path string
+	var form url.Values
+	s, ts := newTestService(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		r.ParseForm()
+		form = r.PostForm
+		fmt.Fprint(w, `{"public_ids":["docs/a"]}`)
+	}))
+	defer ts.Close()
+
+	if err := s.UpdateMetadata("docs/a", map[string]string{"sku": "A|1", "tier": ""}, RawType); err != nil {
+		t.Fatal(err)
+	}
+	if path != "/v1_1/cloudname/raw/metadata" {
+		t.Errorf("wrong path %s", path)
+	}
+	if form.Get("metadata") != `sku=A\|1|tier=` || !reflect.DeepEqual(form["public_ids[]"], []string{"docs/a"}) {
+		t.Errorf("wrong metadata params %v", form)
+	}
+	signed := url.Values{"metadata": form["metadata"], "public_ids": {"docs/a"}, "timestamp": form["timestamp"]}
+	if form.Get("signature") != apiSignature(signed, "secret", SignatureSHA1) || form.Get("api_key") != "key" {
+		t.Error("invalid signature")
+	}
+	if err := s.UpdateMetadata("docs/a", nil, RawType); err == nil {
+		t.Error("should fail without fields")
+	}
+
+	path = ""
+	s.Simulate(true)
+	if err := s.UpdateMetadata("docs/a", map[string]string{"sku": "B"}, RawType); err != nil {
+		t.Fatal(err)
+	}
+	if path != "" {
+		t.Errorf("no request should be sent in simulation mode, got %s", path)
+	}
+	s.Simulate(false)
+}